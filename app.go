@@ -7,10 +7,10 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -25,20 +25,54 @@ import (
 
 // App struct
 type App struct {
-	ctx      context.Context
-	vlinkMu  sync.Mutex
-	vlinkCmd *exec.Cmd
-	settingsMu sync.Mutex
-	settings   AppSettings
+	ctx            context.Context
+	vlinkMu        sync.Mutex
+	vlinkCmd       *exec.Cmd
+	vlinkStop      chan struct{}
+	vlinkDone      chan struct{}
+	vlinkState     VlinkState
+	vlinkAdopted   int
+	vlinkLog       *vlinkLogger
+	backgroundOnce sync.Once
+	settingsMu     sync.Mutex
+	settings       AppSettings
+	chatMu         sync.Mutex
+	chats          map[string]context.CancelFunc
+	sessionsMu     sync.Mutex
+	chatIndex      *chatIndex
+	// subscriptionsMu serialises changes to subscriptions.json; fetches run
+	// outside it.
+	subscriptionsMu sync.Mutex
+	// vlinkConfigMu serialises everything that reads, changes and saves the
+	// live vlink config. It is taken after subscriptionsMu.
+	vlinkConfigMu sync.Mutex
+	// lifetime is cancelled by shutdown; tasks counts the work it waits for.
+	lifetime    context.Context
+	endLifetime context.CancelFunc
+	tasksMu     sync.Mutex
+	tasks       sync.WaitGroup
+	closing     bool
 }
 
 type AppSettings struct {
-	DisplayName   string `json:"displayName"`
-	AutoUpdate    bool   `json:"autoUpdate"`
-	VlinkAutoStart bool  `json:"vlinkAutoStart"`
-	Notes         string `json:"notes"`
-	PomodoroNotifyDesktop bool `json:"pomodoroNotifyDesktop"`
-	PomodoroNotifySound   bool `json:"pomodoroNotifySound"`
+	DisplayName                   string           `json:"displayName"`
+	AutoUpdate                    bool             `json:"autoUpdate"`
+	VlinkAutoStart                bool             `json:"vlinkAutoStart"`
+	Notes                         string           `json:"notes"`
+	PomodoroNotifyDesktop         bool             `json:"pomodoroNotifyDesktop"`
+	PomodoroNotifySound           bool             `json:"pomodoroNotifySound"`
+	DownloadMirror                string           `json:"downloadMirror"`
+	Providers                     []ProviderConfig `json:"providers"`
+	DefaultProvider               string           `json:"defaultProvider"`
+	UpdateChannel                 string           `json:"updateChannel"`
+	ActiveVlinkProfile            string           `json:"activeVlinkProfile"`
+	ProxyMode                     string           `json:"proxyMode"`
+	ProxyURL                      string           `json:"proxyUrl"`
+	VlinkSocksPort                int              `json:"vlinkSocksPort"`
+	VlinkHTTPPort                 int              `json:"vlinkHttpPort"`
+	ProxyProbeURLs                []string         `json:"proxyProbeUrls"`
+	VlinkRunMode                  string           `json:"vlinkRunMode"`
+	VlinkSubscriptionRefreshHours int              `json:"vlinkSubscriptionRefreshHours"`
 }
 
 type VlinkConfig struct {
@@ -48,9 +82,23 @@ type VlinkConfig struct {
 
 var appVersion = "dev"
 
+// updatePublicKey verifies checksums.txt.sig for app updates and vlink
+// installs. Set with -ldflags "-X main.updatePublicKey=<base64 key>".
+var updatePublicKey = ""
+
+// defaultDownloadBaseURL hosts the domour/ and vlink/ release directories.
+// AppSettings.DownloadMirror overrides it at runtime.
+var defaultDownloadBaseURL = "https://qtopie.space/downloads/"
+
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	a := &App{
+		chats:     make(map[string]context.CancelFunc),
+		chatIndex: newChatIndex(),
+	}
+	a.vlinkLog = newVlinkLogger(a.emitVlinkLog)
+	a.lifetime, a.endLifetime = context.WithCancel(context.Background())
+	return a
 }
 
 // startup is called when the app starts. The context is saved
@@ -67,6 +115,28 @@ func (a *App) startup(ctx context.Context) {
 	a.settingsMu.Unlock()
 }
 
+// domReady runs once the frontend has loaded, so events emitted from here
+// reach its listeners. A reload fires it again, hence the sync.Once.
+func (a *App) domReady(ctx context.Context) {
+	a.backgroundOnce.Do(func() {
+		if a.GetSettings().VlinkAutoStart {
+			go a.autoStartVlink()
+		}
+		go a.runUpdateScheduler()
+		go a.runSubscriptionScheduler()
+		go a.clearStartupSentinel()
+	})
+}
+
+// autoStartVlink starts vlink without a caller to return errors to; failures
+// are reported as a stopped vlink:state. A missing config still goes through
+// the vlink:config prompt emitted by StartVlink.
+func (a *App) autoStartVlink() {
+	if _, err := a.StartVlink(); err != nil {
+		a.setVlinkState(VlinkState{State: vlinkStateStopped, ExitCode: vlinkUnknownExitCode, Message: err.Error()})
+	}
+}
+
 // About returns app info for About dialog
 func (a *App) About() string {
 	return fmt.Sprintf("A smart assistant.\nVersion: %s\n\nMade with ♥ in Guangzhou by ©qtopie 2026.", appVersion)
@@ -96,15 +166,32 @@ func (a *App) SaveSettings(next AppSettings) (string, error) {
 
 func defaultSettings() AppSettings {
 	return AppSettings{
-		DisplayName:   "Domour Copilot",
-		AutoUpdate:    true,
-		VlinkAutoStart: false,
-		Notes:         "",
-		PomodoroNotifyDesktop: true,
-		PomodoroNotifySound:   false,
+		DisplayName:                   "Domour Copilot",
+		AutoUpdate:                    true,
+		VlinkAutoStart:                false,
+		Notes:                         "",
+		PomodoroNotifyDesktop:         true,
+		PomodoroNotifySound:           false,
+		DownloadMirror:                "",
+		Providers:                     defaultProviders(),
+		DefaultProvider:               "gemini",
+		UpdateChannel:                 releaseChannelStable,
+		ProxyMode:                     proxyModeVlink,
+		VlinkRunMode:                  vlinkRunModeProcess,
+		VlinkSubscriptionRefreshHours: defaultSubscriptionRefreshHours,
 	}
 }
 
+// releaseBaseURL returns the release directory for product, honoring the
+// configured download mirror.
+func (a *App) releaseBaseURL(product string) string {
+	base := strings.TrimSpace(a.GetSettings().DownloadMirror)
+	if base == "" {
+		base = defaultDownloadBaseURL
+	}
+	return strings.TrimRight(base, "/") + "/" + product + "/"
+}
+
 func settingsFilePath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -122,7 +209,7 @@ func loadSettingsFromDisk() (AppSettings, error) {
 	if err != nil {
 		return AppSettings{}, err
 	}
-	var settings AppSettings
+	settings := defaultSettings()
 	if err := json.Unmarshal(data, &settings); err != nil {
 		return AppSettings{}, err
 	}
@@ -144,6 +231,12 @@ func saveSettingsToDisk(settings AppSettings) error {
 	return os.WriteFile(path, data, 0o600)
 }
 
+// vlinkBinaryPath returns where vlink is installed: the system location or
+// ~/.local/bin, where an install without administrator rights puts it. When
+// both hold a binary the more recently written one is used, which is the
+// one the last install wrote; a system binary updated later, by the app or
+// a package manager, wins over an old fallback copy. If neither holds a
+// binary the system location is returned.
 func vlinkBinaryPath() (string, error) {
 	if runtime.GOOS == "windows" {
 		homeDir, err := os.UserHomeDir()
@@ -152,7 +245,18 @@ func vlinkBinaryPath() (string, error) {
 		}
 		return filepath.Join(homeDir, ".vlink", "vlink.exe"), nil
 	}
-	return "/usr/local/bin/vlink", nil
+	userPath, err := userLocalVlinkBinaryPath()
+	if err != nil {
+		return systemVlinkBinaryPath, nil
+	}
+	userInfo, err := os.Stat(userPath)
+	if err != nil || userInfo.IsDir() {
+		return systemVlinkBinaryPath, nil
+	}
+	if systemInfo, err := os.Stat(systemVlinkBinaryPath); err == nil && !systemInfo.IsDir() && systemInfo.ModTime().After(userInfo.ModTime()) {
+		return systemVlinkBinaryPath, nil
+	}
+	return userPath, nil
 }
 
 func vlinkHomeConfigPath() (string, error) {
@@ -163,7 +267,8 @@ func vlinkHomeConfigPath() (string, error) {
 	return filepath.Join(homeDir, ".vlink", "config.json"), nil
 }
 
-func vlinkConfigExists(path string) bool {
+// fileExists reports whether path is a regular file or a link to one.
+func fileExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && !info.IsDir()
 }
@@ -177,13 +282,13 @@ func ensureVlinkHomeConfig() (string, bool, error) {
 	if err != nil {
 		return "", false, err
 	}
-	if vlinkConfigExists(homeConfig) {
+	if fileExists(homeConfig) {
 		return homeConfig, false, nil
 	}
 	if err := os.MkdirAll(filepath.Dir(homeConfig), 0o755); err != nil {
 		return "", false, err
 	}
-	if err := os.WriteFile(homeConfig, []byte(defaultVlinkConfigContent()), 0o600); err != nil {
+	if err := writeFileAtomic(homeConfig, []byte(defaultVlinkConfigContent()), 0o600); err != nil {
 		return "", false, err
 	}
 	return homeConfig, true, nil
@@ -194,26 +299,35 @@ func resolveVlinkConfigPath() (string, bool, error) {
 	if err != nil {
 		return "", false, err
 	}
-	if vlinkConfigExists(homeConfig) {
+	if fileExists(homeConfig) {
 		return homeConfig, false, nil
 	}
 	if runtime.GOOS != "windows" {
 		systemConfig := "/etc/vlink/config.json"
-		if vlinkConfigExists(systemConfig) {
+		if fileExists(systemConfig) {
 			return systemConfig, false, nil
 		}
 	}
 	return ensureVlinkHomeConfig()
 }
 
-// StartVlink starts the vlink process with the configured file.
+// StartVlink starts the vlink process with the configured file and keeps it
+// running under the supervisor until StopVlink is called. In the systemd
+// run mode it starts the vlink user service instead.
 func (a *App) StartVlink() (string, error) {
+	if a.vlinkUsesSystemd() {
+		return a.startVlinkService()
+	}
+
 	a.vlinkMu.Lock()
 	defer a.vlinkMu.Unlock()
 
-	if a.vlinkCmd != nil && a.vlinkCmd.Process != nil {
+	if a.vlinkStop != nil || a.vlinkAdopted != 0 {
 		return "vlink is already running", nil
 	}
+	if err := a.checkVlinkConflict(0); err != nil {
+		return "vlink is already running outside the app", err
+	}
 
 	binaryPath, err := vlinkBinaryPath()
 	if err != nil {
@@ -232,23 +346,19 @@ func (a *App) StartVlink() (string, error) {
 		args = append(args, "-config", configPath)
 	}
 
-	cmd := exec.Command(binaryPath, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
+	a.setVlinkStateLocked(VlinkState{State: vlinkStateStarting})
+	cmd := a.newVlinkCommand(binaryPath, args)
 	if err := cmd.Start(); err != nil {
+		a.setVlinkStateLocked(VlinkState{State: vlinkStateStopped, ExitCode: -1, Message: err.Error()})
 		return "failed to start vlink", err
 	}
 
+	stop := make(chan struct{})
+	done := make(chan struct{})
 	a.vlinkCmd = cmd
-	go func() {
-		_ = cmd.Wait()
-		a.vlinkMu.Lock()
-		defer a.vlinkMu.Unlock()
-		if a.vlinkCmd == cmd {
-			a.vlinkCmd = nil
-		}
-	}()
+	a.vlinkStop = stop
+	a.vlinkDone = done
+	go a.superviseVlink(cmd, binaryPath, args, stop, done)
 
 	return "vlink started", nil
 }
@@ -265,47 +375,77 @@ func (a *App) GetVlinkConfig() (VlinkConfig, error) {
 	return VlinkConfig{Path: path, Content: string(data)}, nil
 }
 
-func (a *App) SaveVlinkConfig(content string) (string, error) {
-	path, _, err := ensureVlinkHomeConfig()
-	if err != nil {
-		return "", err
+// SaveVlinkConfig validates content and only writes it when there are no
+// errors. Rejected configs come back with Saved false and the diagnostics
+// to show, not as an error. Every save is kept as a version with the
+// optional comment.
+func (a *App) SaveVlinkConfig(content string, comment string) (VlinkConfigValidation, error) {
+	a.vlinkConfigMu.Lock()
+	defer a.vlinkConfigMu.Unlock()
+	return a.saveVlinkConfig(content, comment)
+}
+
+// StopVlink stops vlink in whichever run mode it was started, including a
+// vlink adopted with AdoptVlink.
+func (a *App) StopVlink() (string, error) {
+	if stopped, err := a.stopAdoptedVlink(); stopped {
+		return "vlink stopped", err
 	}
-	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
-		return "", err
+	if a.vlinkUsesSystemd() {
+		// A child started before switching modes would keep the ports busy.
+		if _, err := a.stopVlinkProcess(); err != nil {
+			return "", err
+		}
+		return a.stopVlinkService()
 	}
-	return "vlink config saved", nil
+	return a.stopVlinkProcess()
 }
 
-// StopVlink stops the running vlink process.
-func (a *App) StopVlink() (string, error) {
+// stopVlinkProcess stops the vlink child process and its supervisor.
+func (a *App) stopVlinkProcess() (string, error) {
 	a.vlinkMu.Lock()
-	defer a.vlinkMu.Unlock()
-
-	if a.vlinkCmd == nil || a.vlinkCmd.Process == nil {
+	if a.vlinkStop == nil {
+		a.vlinkMu.Unlock()
 		return "vlink is not running", nil
 	}
+	close(a.vlinkStop)
+	cmd := a.vlinkCmd
+	done := a.vlinkDone
+	a.vlinkMu.Unlock()
 
-	_ = a.vlinkCmd.Process.Signal(os.Interrupt)
-
-	waitCh := make(chan error, 1)
-	go func(cmd *exec.Cmd) {
-		waitCh <- cmd.Wait()
-	}(a.vlinkCmd)
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Signal(os.Interrupt)
+	}
 
 	select {
-	case <-time.After(3 * time.Second):
-		_ = a.vlinkCmd.Process.Kill()
-		_ = a.vlinkCmd.Wait()
-	case <-waitCh:
+	case <-done:
+		return "vlink stopped", nil
+	case <-time.After(vlinkStopGrace):
 	}
 
-	a.vlinkCmd = nil
-	return "vlink stopped", nil
+	// During a backoff cmd is the process that already exited, and the
+	// supervisor may have started its replacement since; kill the current one.
+	a.vlinkMu.Lock()
+	if a.vlinkCmd != nil {
+		cmd = a.vlinkCmd
+	}
+	a.vlinkMu.Unlock()
+	if cmd != nil && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-done:
+		return "vlink stopped", nil
+	case <-time.After(vlinkKillWait):
+		return "", fmt.Errorf("vlink did not exit within %s of being killed", vlinkKillWait)
+	}
 }
 
-// IsVlinkPortAlive checks if 127.0.0.1:1080 is accepting TCP connections.
+// IsVlinkPortAlive checks if vlink's SOCKS inbound is accepting TCP
+// connections.
 func (a *App) IsVlinkPortAlive() bool {
-	conn, err := net.DialTimeout("tcp", "127.0.0.1:1080", 500*time.Millisecond)
+	socks, _ := a.vlinkProxyEndpoints()
+	conn, err := net.DialTimeout("tcp", socks.Address, 500*time.Millisecond)
 	if err != nil {
 		return false
 	}
@@ -328,15 +468,33 @@ type GeminiAttachment struct {
 	IsBinary bool   `json:"isBinary"`
 }
 
-// ChatWithGeminiWithAttachments sends prompt and attachments to Gemini CLI using yolo mode.
+// ChatWithGeminiWithAttachments sends prompt and attachments to Gemini CLI
+// using yolo mode, with the gemini provider's configured model.
 func (a *App) ChatWithGeminiWithAttachments(prompt string, attachments []GeminiAttachment) (string, error) {
 	trimmed := strings.TrimSpace(prompt)
 	if trimmed == "" {
 		return "", nil
 	}
+	provider, err := a.resolveProvider("gemini")
+	if err != nil {
+		return "", err
+	}
+
+	taskCtx, done := a.beginTask()
+	defer done()
+	ctx, cancel := context.WithTimeout(taskCtx, 90*time.Second)
+	defer cancel()
 
+	return provider.Chat(ctx, []ChatTurn{
+		{Role: "user", Content: buildPromptWithAttachments(trimmed, attachments)},
+	})
+}
+
+// buildPromptWithAttachments appends attachments to the prompt as plain text,
+// which every provider accepts.
+func buildPromptWithAttachments(prompt string, attachments []GeminiAttachment) string {
 	var combined strings.Builder
-	combined.WriteString(trimmed)
+	combined.WriteString(prompt)
 
 	if len(attachments) > 0 {
 		combined.WriteString("\n\nAttachments:\n")
@@ -358,39 +516,24 @@ func (a *App) ChatWithGeminiWithAttachments(prompt string, attachments []GeminiA
 			}
 		}
 	}
+	return combined.String()
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "gemini", "chat", "--yolo")
-	cmd.Stdin = strings.NewReader(combined.String())
-	finalHTTPProxy := "http://127.0.0.1:8118"
-	env := append([]string{}, os.Environ()...)
-	env = append(env, fmt.Sprintf("HTTP_PROXY=%s", finalHTTPProxy))
-	env = append(env, fmt.Sprintf("HTTPS_PROXY=%s", finalHTTPProxy))
-	cmd.Env = env
-
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("gemini cli timeout")
+// promptWithAttachmentNote is the prompt as a chat session stores it: the
+// attachments are named but their content, which would otherwise be re-sent
+// with every later turn, is not.
+func promptWithAttachmentNote(prompt string, attachments []GeminiAttachment) string {
+	var names []string
+	for _, attachment := range attachments {
+		name := strings.TrimSpace(attachment.Name)
+		if name != "" && strings.TrimSpace(attachment.Content) != "" {
+			names = append(names, name)
 		}
-		errMsg := strings.TrimSpace(stderr.String())
-		if errMsg == "" {
-			errMsg = err.Error()
-		}
-		return "", fmt.Errorf("gemini cli error: %s", errMsg)
 	}
-
-	output := strings.TrimSpace(stdout.String())
-	if output == "" {
-		return "", fmt.Errorf("gemini cli returned empty response")
+	if len(names) == 0 {
+		return prompt
 	}
-	return output, nil
+	return prompt + "\n\n(Attachments: " + strings.Join(names, ", ") + ")"
 }
 
 // SelfUpdate downloads and applies the latest archive from the downloads directory.
@@ -402,10 +545,16 @@ func (a *App) SelfUpdate() (string, error) {
 // SelfUpdateFromArchive downloads and applies an update for the given version.
 // If version is empty, "latest" is used.
 func (a *App) SelfUpdateFromArchive(version string) (string, error) {
-	baseURL := "https://qtopie.space/downloads/domour/"
+	ctx, done := a.beginTask()
+	defer done()
+	baseURL := a.releaseBaseURL("domour")
+	checksums, err := fetchChecksums(baseURL)
+	if err != nil {
+		return "", err
+	}
 	finalVersion := strings.TrimSpace(version)
 	if finalVersion == "" || finalVersion == "latest" {
-		latest, err := fetchLatestVersionFromChecksums(baseURL, "domour-copilot")
+		latest, err := latestVersionFromChecksums(checksums, "domour-copilot", a.GetSettings().UpdateChannel)
 		if err != nil {
 			return "", err
 		}
@@ -415,25 +564,31 @@ func (a *App) SelfUpdateFromArchive(version string) (string, error) {
 	if fileName == "" {
 		return "", fmt.Errorf("unsupported platform for update")
 	}
-	url := baseURL + fileName
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download update: %w", err)
+	if err := keepCurrentVersion(""); err != nil {
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("update download failed: %s", resp.Status)
+	// Prefer a binary patch from the running version; anything short of a
+	// failed restore falls back to the full archive.
+	err = a.applyPatchUpdate(ctx, baseURL, checksums, finalVersion)
+	if err == nil {
+		return "update applied, please restart the app", nil
+	}
+	var restoreErr *patchRestoreError
+	if errors.As(err, &restoreErr) {
+		return "", err
 	}
 
-	archiveData, err := io.ReadAll(resp.Body)
+	archivePath, err := downloadReleaseFile(ctx, baseURL, fileName, a.emitDownloadProgress)
 	if err != nil {
-		return "", fmt.Errorf("failed to read update: %w", err)
+		return "", err
+	}
+	defer os.Remove(archivePath)
+	if err := verifyFileChecksum(checksums, fileName, archivePath); err != nil {
+		return "", err
 	}
 
-	binaryData, err := extractBinaryFromArchive(archiveData)
+	binaryData, err := extractBinaryFromArchive(archivePath)
 	if err != nil {
 		return "", err
 	}
@@ -458,24 +613,24 @@ func buildArchiveFileName(version string) string {
 	return fmt.Sprintf("%s_%s_%s_%s.tar.gz", name, version, goos, goarch)
 }
 
-func extractBinaryFromArchive(data []byte) ([]byte, error) {
+func extractBinaryFromArchive(archivePath string) ([]byte, error) {
 	expected := "domour-copilot"
 	if runtime.GOOS == "windows" {
 		expected = "domour-copilot.exe"
 	}
 
 	if runtime.GOOS == "windows" {
-		return extractFromZip(data, expected)
+		return extractFromZip(archivePath, expected)
 	}
-	return extractFromTarGz(data, expected)
+	return extractFromTarGz(archivePath, expected)
 }
 
-func extractFromZip(data []byte, expected string) ([]byte, error) {
-	readerAt := bytes.NewReader(data)
-	zipReader, err := zip.NewReader(readerAt, int64(len(data)))
+func extractFromZip(archivePath string, expected string) ([]byte, error) {
+	zipReader, err := zip.OpenReader(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open zip: %w", err)
 	}
+	defer zipReader.Close()
 	for _, file := range zipReader.File {
 		if filepath.Base(file.Name) != expected {
 			continue
@@ -493,8 +648,14 @@ func extractFromZip(data []byte, expected string) ([]byte, error) {
 	return nil, fmt.Errorf("binary %s not found in zip", expected)
 }
 
-func extractFromTarGz(data []byte, expected string) ([]byte, error) {
-	gzReader, err := gzip.NewReader(bytes.NewReader(data))
+func extractFromTarGz(archivePath string, expected string) ([]byte, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open gzip: %w", err)
 	}
@@ -529,57 +690,40 @@ func (a *App) IsVlinkInstalled() bool {
 	return err == nil
 }
 
-func (a *App) InstallVlink(version string, sudoPassword string) (string, error) {
+// InstallVlink downloads vlink and installs it, asking for administrator
+// rights through the desktop's own prompt; see installVlinkBinary.
+func (a *App) InstallVlink(version string) (string, error) {
 	if runtime.GOOS == "windows" {
 		return a.installVlinkForWindows(version)
 	}
-	trimmedPassword := strings.TrimSpace(sudoPassword)
-	if trimmedPassword == "" {
-		return "", fmt.Errorf("sudo password is required")
-	}
 
 	a.emitVlinkInstallStatus("开始安装 vlink")
 
-	binaryData, err := downloadVlinkBinary(version)
+	ctx, done := a.beginTask()
+	defer done()
+	binaryData, err := downloadVlinkBinary(ctx, a.releaseBaseURL("vlink"), version, a.emitDownloadProgress)
 	if err != nil {
 		a.emitVlinkInstallStatus("vlink 下载失败")
 		return "", err
 	}
 	a.emitVlinkInstallStatus("vlink 下载完成")
 
-	tmpFile, err := os.CreateTemp("", "vlink-*")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
-	}
-	defer os.Remove(tmpFile.Name())
-
-	if _, err := tmpFile.Write(binaryData); err != nil {
-		_ = tmpFile.Close()
-		a.emitVlinkInstallStatus("写入临时文件失败")
-		return "", fmt.Errorf("failed to write temp file: %w", err)
-	}
-	if err := tmpFile.Close(); err != nil {
-		a.emitVlinkInstallStatus("写入临时文件失败")
-		return "", fmt.Errorf("failed to close temp file: %w", err)
-	}
-
-	a.emitVlinkInstallStatus("正在写入 /usr/local/bin/vlink（需要 sudo 授权）")
-	installCmd := exec.Command("sudo", "-S", "install", "-m", "0755", tmpFile.Name(), "/usr/local/bin/vlink")
-	installCmd.Stdin = strings.NewReader(trimmedPassword + "\n")
-	output, err := installCmd.CombinedOutput()
+	path, err := a.installVlinkBinary(binaryData)
 	if err != nil {
 		a.emitVlinkInstallStatus("安装失败")
-		return "", fmt.Errorf("install failed: %s", strings.TrimSpace(string(output)))
+		return "", err
 	}
 
-	a.emitVlinkInstallStatus("安装完成")
-	return "vlink installed", nil
+	a.emitVlinkInstallStatus("安装完成：" + path)
+	return "vlink installed to " + path, nil
 }
 
 func (a *App) installVlinkForWindows(version string) (string, error) {
 	a.emitVlinkInstallStatus("开始安装 vlink")
 
-	binaryData, err := downloadVlinkBinary(version)
+	ctx, done := a.beginTask()
+	defer done()
+	binaryData, err := downloadVlinkBinary(ctx, a.releaseBaseURL("vlink"), version, a.emitDownloadProgress)
 	if err != nil {
 		a.emitVlinkInstallStatus("vlink 下载失败")
 		return "", err
@@ -622,11 +766,14 @@ func (a *App) installVlinkForWindows(version string) (string, error) {
 	return "vlink installed", nil
 }
 
-func downloadVlinkBinary(version string) ([]byte, error) {
-	baseURL := "https://qtopie.space/downloads/vlink/"
+func downloadVlinkBinary(ctx context.Context, baseURL string, version string, onProgress func(DownloadProgress)) ([]byte, error) {
+	checksums, err := fetchChecksums(baseURL)
+	if err != nil {
+		return nil, err
+	}
 	finalVersion := strings.TrimSpace(version)
 	if finalVersion == "" || finalVersion == "latest" {
-		latest, err := fetchLatestVersionFromChecksums(baseURL, "vlink")
+		latest, err := latestVersionFromChecksums(checksums, "vlink", releaseChannelStable)
 		if err != nil {
 			return nil, err
 		}
@@ -636,22 +783,13 @@ func downloadVlinkBinary(version string) ([]byte, error) {
 	if fileName == "" {
 		return nil, fmt.Errorf("unsupported platform for vlink")
 	}
-	url := baseURL + fileName
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Get(url)
+	archivePath, err := downloadReleaseFile(ctx, baseURL, fileName, onProgress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download vlink: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("vlink download failed: %s", resp.Status)
+		return nil, err
 	}
-
-	archiveData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read vlink archive: %w", err)
+	defer os.Remove(archivePath)
+	if err := verifyFileChecksum(checksums, fileName, archivePath); err != nil {
+		return nil, err
 	}
 
 	expected := "vlink"
@@ -660,9 +798,9 @@ func downloadVlinkBinary(version string) ([]byte, error) {
 	}
 
 	if runtime.GOOS == "windows" {
-		return extractFromZip(archiveData, expected)
+		return extractFromZip(archivePath, expected)
 	}
-	return extractFromTarGz(archiveData, expected)
+	return extractFromTarGz(archivePath, expected)
 }
 
 func buildVlinkArchiveFileName(version string) string {
@@ -675,35 +813,58 @@ func buildVlinkArchiveFileName(version string) string {
 	return fmt.Sprintf("%s_%s_%s_%s.tar.gz", name, version, goos, goarch)
 }
 
-func fetchLatestVersionFromChecksums(baseURL string, prefix string) (string, error) {
-	url := strings.TrimRight(baseURL, "/") + "/checksums.txt"
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download checksums: %w", err)
-	}
-	defer resp.Body.Close()
+// Release channels, from most to least conservative. Each channel also
+// accepts releases from the channels before it.
+const (
+	releaseChannelStable  = "stable"
+	releaseChannelBeta    = "beta"
+	releaseChannelNightly = "nightly"
+)
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("checksums download failed: %s", resp.Status)
-	}
+var releaseChannelNames = []string{releaseChannelStable, releaseChannelBeta, releaseChannelNightly}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read checksums: %w", err)
+// releaseChannelRank orders channels; unknown names fall back to stable.
+func releaseChannelRank(channel string) int {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	for rank, name := range releaseChannelNames {
+		if name == channel {
+			return rank
+		}
 	}
+	return 0
+}
 
-	versions := extractVersionsFromChecksums(string(body), prefix)
-	if len(versions) == 0 {
-		return "", fmt.Errorf("no versions found for %s in checksums", prefix)
+// versionReleaseChannel classifies a version by its pre-release tag: none is
+// stable, alpha/beta/rc are beta, and anything else (nightly, dev, a commit
+// hash) is nightly.
+func versionReleaseChannel(ver string) string {
+	pre, _ := splitSemver(ver)
+	if pre == nil {
+		return releaseChannelStable
+	}
+	tag := strings.ToLower(pre[0])
+	for _, prefix := range []string{"alpha", "beta", "rc"} {
+		if strings.HasPrefix(tag, prefix) {
+			return releaseChannelBeta
+		}
 	}
+	return releaseChannelNightly
+}
 
-	latest := versions[0]
-	for _, v := range versions[1:] {
-		if compareSemver(v, latest) > 0 {
+func latestVersionFromChecksums(checksums string, prefix string, channel string) (string, error) {
+	rank := releaseChannelRank(channel)
+	var latest string
+	for _, v := range extractVersionsFromChecksums(checksums, prefix) {
+		if releaseChannelRank(versionReleaseChannel(v)) > rank {
+			continue
+		}
+		if latest == "" || compareSemver(v, latest) > 0 {
 			latest = v
 		}
 	}
+	if latest == "" {
+		return "", fmt.Errorf("no %s versions found for %s in checksums", releaseChannelNames[rank], prefix)
+	}
 	return latest, nil
 }
 
@@ -737,30 +898,85 @@ func readSemverWithV(input string) string {
 	return ""
 }
 
+// isValidSemver accepts v-prefixed versions with two or three numeric
+// segments and an optional semver pre-release ("-beta.1") and build
+// metadata ("+build.5").
 func isValidSemver(ver string) bool {
 	if !strings.HasPrefix(ver, "v") {
 		return false
 	}
-	segments := strings.Split(ver[1:], ".")
+	core := ver[1:]
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		if !validSemverIdentifiers(core[i+1:], false) {
+			return false
+		}
+		core = core[:i]
+	}
+	if i := strings.IndexByte(core, '-'); i >= 0 {
+		if !validSemverIdentifiers(core[i+1:], true) {
+			return false
+		}
+		core = core[:i]
+	}
+	segments := strings.Split(core, ".")
 	if len(segments) < 2 || len(segments) > 3 {
 		return false
 	}
 	for _, seg := range segments {
-		if seg == "" {
+		if seg == "" || !isNumeric(seg) {
+			return false
+		}
+	}
+	return true
+}
+
+// validSemverIdentifiers checks dot-separated [0-9A-Za-z-] identifiers.
+// Numeric pre-release identifiers may not have leading zeros.
+func validSemverIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
 			return false
 		}
-		for _, ch := range seg {
-			if ch < '0' || ch > '9' {
+		for _, ch := range id {
+			if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '-') {
 				return false
 			}
 		}
+		if prerelease && len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return false
+		}
 	}
 	return true
 }
 
+func isNumeric(s string) bool {
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// splitSemver returns the pre-release identifiers (nil for a release) and the
+// numeric core of a version. Build metadata is dropped.
+func splitSemver(ver string) ([]string, string) {
+	core := strings.TrimPrefix(ver, "v")
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		core = core[:i]
+	}
+	if i := strings.IndexByte(core, '-'); i >= 0 {
+		return strings.Split(core[i+1:], "."), core[:i]
+	}
+	return nil, core
+}
+
+// compareSemver orders versions by semver precedence: numeric segments
+// first, then a release above any of its pre-releases, then pre-release
+// identifiers left to right. Build metadata is ignored.
 func compareSemver(a string, b string) int {
-	parse := func(v string) []int {
-		parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	parse := func(core string) []int {
+		parts := strings.Split(core, ".")
 		out := []int{0, 0, 0}
 		for i := 0; i < len(parts) && i < 3; i++ {
 			var n int
@@ -769,8 +985,10 @@ func compareSemver(a string, b string) int {
 		}
 		return out
 	}
-	va := parse(a)
-	vb := parse(b)
+	preA, coreA := splitSemver(a)
+	preB, coreB := splitSemver(b)
+	va := parse(coreA)
+	vb := parse(coreB)
 	for i := 0; i < 3; i++ {
 		if va[i] > vb[i] {
 			return 1
@@ -779,9 +997,51 @@ func compareSemver(a string, b string) int {
 			return -1
 		}
 	}
+
+	switch {
+	case preA == nil && preB == nil:
+		return 0
+	case preA == nil:
+		return 1
+	case preB == nil:
+		return -1
+	}
+	for i := 0; i < len(preA) && i < len(preB); i++ {
+		if c := comparePrereleaseIdentifier(preA[i], preB[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(preA) > len(preB):
+		return 1
+	case len(preA) < len(preB):
+		return -1
+	}
 	return 0
 }
 
+// comparePrereleaseIdentifier compares numeric identifiers numerically and
+// ranks them below alphanumeric ones, which compare in ASCII order.
+func comparePrereleaseIdentifier(a string, b string) int {
+	numA, numB := isNumeric(a), isNumeric(b)
+	switch {
+	case numA && numB:
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) > len(b) {
+				return 1
+			}
+			return -1
+		}
+	case numA:
+		return -1
+	case numB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
 func (a *App) emitVlinkInstallStatus(message string) {
 	if a.ctx == nil {
 		return
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	chatSearchMaxResults = 50
+	chatSnippetRadius    = 40
+)
+
+// ChatSearchResult is one message matching a SearchChats query.
+type ChatSearchResult struct {
+	SessionID    string `json:"sessionId"`
+	SessionTitle string `json:"sessionTitle"`
+	MessageIndex int    `json:"messageIndex"`
+	Role         string `json:"role"`
+	Snippet      string `json:"snippet"`
+	CreatedAt    int64  `json:"createdAt"`
+}
+
+type chatDocID struct {
+	sessionID string
+	index     int
+}
+
+type chatDoc struct {
+	role      string
+	content   string
+	createdAt int64
+}
+
+// chatIndex is an in-memory inverted index over all session messages. It is
+// built from the session files on first search and kept current as messages
+// are recorded.
+type chatIndex struct {
+	mu       sync.Mutex
+	built    bool
+	postings map[string]map[chatDocID]int
+	docs     map[chatDocID]chatDoc
+	sessions map[string][]chatDocID
+	titles   map[string]string
+}
+
+func newChatIndex() *chatIndex {
+	return &chatIndex{
+		postings: make(map[string]map[chatDocID]int),
+		docs:     make(map[chatDocID]chatDoc),
+		sessions: make(map[string][]chatDocID),
+		titles:   make(map[string]string),
+	}
+}
+
+// SearchChats finds messages across all chat sessions containing every term
+// of query. Chinese, Japanese and Korean text is matched by character
+// bigrams, everything else by whole words.
+func (a *App) SearchChats(query string) ([]ChatSearchResult, error) {
+	if len(searchTokens(query, true)) == 0 {
+		return []ChatSearchResult{}, nil
+	}
+	if err := a.ensureChatIndex(); err != nil {
+		return nil, err
+	}
+	return a.chatIndex.search(query, chatSearchMaxResults), nil
+}
+
+func (a *App) ensureChatIndex() error {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	a.chatIndex.mu.Lock()
+	built := a.chatIndex.built
+	a.chatIndex.mu.Unlock()
+	if built {
+		return nil
+	}
+
+	ids, err := listChatSessionIDs()
+	if err != nil {
+		return err
+	}
+	a.chatIndex.mu.Lock()
+	defer a.chatIndex.mu.Unlock()
+	for _, id := range ids {
+		session, err := loadChatSession(id)
+		if err != nil {
+			continue
+		}
+		a.chatIndex.setTitleLocked(session.ID, session.Title)
+		for i, msg := range session.Messages {
+			a.chatIndex.addLocked(chatDocID{sessionID: session.ID, index: i}, chatDoc{
+				role:      msg.Role,
+				content:   msg.Content,
+				createdAt: msg.CreatedAt,
+			})
+		}
+	}
+	a.chatIndex.built = true
+	return nil
+}
+
+// indexChatMessage, renameIndexedSession and removeIndexedSession keep a
+// built index in sync; before the first search they are no-ops because the
+// build reads everything from disk.
+func (idx *chatIndex) indexChatMessage(session ChatSession, index int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.built {
+		return
+	}
+	msg := session.Messages[index]
+	idx.setTitleLocked(session.ID, session.Title)
+	idx.addLocked(chatDocID{sessionID: session.ID, index: index}, chatDoc{
+		role:      msg.Role,
+		content:   msg.Content,
+		createdAt: msg.CreatedAt,
+	})
+}
+
+func (idx *chatIndex) renameIndexedSession(sessionID string, title string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.built {
+		idx.setTitleLocked(sessionID, title)
+	}
+}
+
+func (idx *chatIndex) removeIndexedSession(sessionID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range idx.sessions[sessionID] {
+		for _, token := range searchTokens(idx.docs[id].content, false) {
+			delete(idx.postings[token], id)
+			if len(idx.postings[token]) == 0 {
+				delete(idx.postings, token)
+			}
+		}
+		delete(idx.docs, id)
+	}
+	delete(idx.sessions, sessionID)
+	delete(idx.titles, sessionID)
+}
+
+func (idx *chatIndex) setTitleLocked(sessionID string, title string) {
+	idx.titles[sessionID] = title
+}
+
+func (idx *chatIndex) addLocked(id chatDocID, doc chatDoc) {
+	if _, exists := idx.docs[id]; exists {
+		return
+	}
+	idx.docs[id] = doc
+	idx.sessions[id.sessionID] = append(idx.sessions[id.sessionID], id)
+	for _, token := range searchTokens(doc.content, false) {
+		postings, ok := idx.postings[token]
+		if !ok {
+			postings = make(map[chatDocID]int)
+			idx.postings[token] = postings
+		}
+		postings[id]++
+	}
+}
+
+func (idx *chatIndex) search(query string, limit int) []ChatSearchResult {
+	tokens := uniqueStrings(searchTokens(query, true))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// Intersect postings, starting from the rarest token.
+	sort.Slice(tokens, func(i, j int) bool {
+		return len(idx.postings[tokens[i]]) < len(idx.postings[tokens[j]])
+	})
+	scores := make(map[chatDocID]int)
+	for id, tf := range idx.postings[tokens[0]] {
+		scores[id] = tf
+	}
+	for _, token := range tokens[1:] {
+		postings := idx.postings[token]
+		for id, score := range scores {
+			tf, ok := postings[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] = score + tf
+		}
+	}
+
+	ids := make([]chatDocID, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return idx.docs[ids[i]].createdAt > idx.docs[ids[j]].createdAt
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	// Snippets look for the same terms the index matched, so a query such as
+	// "vlink配置" finds "vlink 的配置" by its bigrams.
+	terms := searchTokens(query, true)
+	results := make([]ChatSearchResult, 0, len(ids))
+	for _, id := range ids {
+		doc := idx.docs[id]
+		results = append(results, ChatSearchResult{
+			SessionID:    id.sessionID,
+			SessionTitle: idx.titles[id.sessionID],
+			MessageIndex: id.index,
+			Role:         doc.role,
+			Snippet:      searchSnippet(doc.content, terms),
+			CreatedAt:    doc.createdAt,
+		})
+	}
+	return results
+}
+
+// searchTokens lowercases text and splits it into index terms: whole words
+// for scripts that use spaces, and overlapping character bigrams for CJK runs.
+// Documents also index CJK unigrams so single-character queries match; a query
+// only uses unigrams for runs of one character.
+func searchTokens(text string, query bool) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 || (!query && len(cjk) > 0) {
+			for _, r := range cjk {
+				tokens = append(tokens, string(r))
+			}
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// searchSnippet returns the text around the first matching term.
+func searchSnippet(content string, terms []string) string {
+	runes := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	if len(lower) != len(runes) {
+		runes = lower
+	}
+	lowerText := string(lower)
+
+	pos := -1
+	for _, term := range terms {
+		if i := strings.Index(lowerText, term); i >= 0 && (pos == -1 || i < pos) {
+			pos = i
+		}
+	}
+	start := 0
+	if pos > 0 {
+		start = utf8.RuneCountInString(lowerText[:pos]) - chatSnippetRadius
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + 2*chatSnippetRadius
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query bool
+		want  []string
+	}{
+		{"latin words", "Hello, vlink-Config 2!", false, []string{"hello", "vlink", "config", "2"}},
+		{"cjk document", "配置文件", false, []string{"配", "置", "文", "件", "配置", "置文", "文件"}},
+		{"cjk query uses bigrams only", "配置文件", true, []string{"配置", "置文", "文件"}},
+		{"single cjk character query", "配", true, []string{"配"}},
+		{"mixed run", "vlink配置", true, []string{"vlink", "配置"}},
+		{"mixed with single character", "用vlink的配置", true, []string{"用", "vlink", "的配", "配置"}},
+		{"kana and hangul", "カタカナ 한국", true, []string{"カタ", "タカ", "カナ", "한국"}},
+		{"punctuation only", "，。!?", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchTokens(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchTokens(%q, %v) = %q, want %q", tt.text, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestChatIndex(docs map[chatDocID]chatDoc) *chatIndex {
+	idx := newChatIndex()
+	idx.built = true
+	for id, doc := range docs {
+		idx.setTitleLocked(id.sessionID, "title "+id.sessionID)
+		idx.addLocked(id, doc)
+	}
+	return idx
+}
+
+func TestChatIndexSearch(t *testing.T) {
+	long := "这是一段很长的开头，用来把匹配的位置推到后面，这样摘要就不能从消息的第一个字开始显示了。然后才说到 vlink 的配置文件在哪里。"
+	idx := newTestChatIndex(map[chatDocID]chatDoc{
+		{"a", 0}: {role: "user", content: "怎么修改 vlink 的配置？", createdAt: 1},
+		{"a", 1}: {role: "assistant", content: "vlink 的配置在 ~/.vlink/config.json，改完配置后重启 vlink。", createdAt: 2},
+		{"b", 0}: {role: "user", content: long, createdAt: 3},
+		{"b", 1}: {role: "assistant", content: "Restart the proxy after editing.", createdAt: 4},
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		want     []chatDocID
+		snippets []string
+	}{
+		{
+			name:  "ranked by term frequency, then newest",
+			query: "vlink配置",
+			want:  []chatDocID{{"a", 1}, {"b", 0}, {"a", 0}},
+			snippets: []string{
+				"vlink 的配置在 ~/.vlink/config.json，改完配置后重启 vlink。",
+				"…用来把匹配的位置推到后面，这样摘要就不能从消息的第一个字开始显示了。然后才说到 vlink 的配置文件在哪里。",
+				"怎么修改 vlink 的配置？",
+			},
+		},
+		{
+			name:     "latin words match case-insensitively",
+			query:    "RESTART proxy",
+			want:     []chatDocID{{"b", 1}},
+			snippets: []string{"Restart the proxy after editing."},
+		},
+		{
+			name:     "single character query",
+			query:    "改",
+			want:     []chatDocID{{"a", 1}, {"a", 0}},
+			snippets: []string{"vlink 的配置在 ~/.vlink/config.json，改完配置后重启 vlink。", "怎么修改 vlink 的配置？"},
+		},
+		{
+			name:  "every term must match",
+			query: "vlink proxy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := idx.search(tt.query, chatSearchMaxResults)
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d results %+v, want %d", len(results), results, len(tt.want))
+			}
+			for i, result := range results {
+				got := chatDocID{result.SessionID, result.MessageIndex}
+				if got != tt.want[i] {
+					t.Errorf("result %d = %v, want %v", i, got, tt.want[i])
+				}
+				if result.Snippet != tt.snippets[i] {
+					t.Errorf("result %d snippet = %q, want %q", i, result.Snippet, tt.snippets[i])
+				}
+				if result.SessionTitle != "title "+got.sessionID {
+					t.Errorf("result %d title = %q", i, result.SessionTitle)
+				}
+			}
+		})
+	}
+}
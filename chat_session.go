@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultChatSessionTitle = "新会话"
+	// Prior turns sent with each prompt are capped by count and by size, in
+	// characters rather than bytes, so long conversations do not blow past
+	// the model's context window.
+	chatHistoryMaxTurns = 20
+	chatHistoryMaxChars = 24000
+)
+
+// ChatSessionMessage is one stored turn of a chat session. Partial marks
+// the output of a cancelled or failed request; it is kept for the user but
+// not sent back to the model.
+type ChatSessionMessage struct {
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Partial   bool   `json:"partial,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// ChatSession is a persisted conversation under ~/.domour/sessions.
+type ChatSession struct {
+	ID         string               `json:"id"`
+	Title      string               `json:"title"`
+	ProviderID string               `json:"providerId"`
+	CreatedAt  int64                `json:"createdAt"`
+	UpdatedAt  int64                `json:"updatedAt"`
+	Messages   []ChatSessionMessage `json:"messages"`
+}
+
+// ChatSessionSummary is a ChatSession without its messages, for listing.
+type ChatSessionSummary struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	ProviderID   string `json:"providerId"`
+	CreatedAt    int64  `json:"createdAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+	MessageCount int    `json:"messageCount"`
+}
+
+// CreateChatSession starts a new, empty chat session.
+func (a *App) CreateChatSession(title string) (ChatSessionSummary, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = defaultChatSessionTitle
+	}
+	now := time.Now().UnixMilli()
+	session := ChatSession{
+		ID:        newRequestID(),
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	if err := saveChatSession(session); err != nil {
+		return ChatSessionSummary{}, err
+	}
+	return session.summary(), nil
+}
+
+// ListChatSessions returns all sessions, most recently updated first.
+func (a *App) ListChatSessions() ([]ChatSessionSummary, error) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	ids, err := listChatSessionIDs()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]ChatSessionSummary, 0, len(ids))
+	for _, id := range ids {
+		session, err := loadChatSession(id)
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, session.summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt > summaries[j].UpdatedAt
+	})
+	return summaries, nil
+}
+
+// LoadChatSession returns a session with its full message history.
+func (a *App) LoadChatSession(id string) (ChatSession, error) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	return loadChatSession(id)
+}
+
+// RenameChatSession changes a session's title.
+func (a *App) RenameChatSession(id string, title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("session title is empty")
+	}
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	session, err := loadChatSession(id)
+	if err != nil {
+		return "", err
+	}
+	session.Title = title
+	session.UpdatedAt = time.Now().UnixMilli()
+	if err := saveChatSession(session); err != nil {
+		return "", err
+	}
+	a.chatIndex.renameIndexedSession(id, title)
+	return "session renamed", nil
+}
+
+// DeleteChatSession removes a session and its history from disk.
+func (a *App) DeleteChatSession(id string) (string, error) {
+	path, err := chatSessionPath(id)
+	if err != nil {
+		return "", err
+	}
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	a.chatIndex.removeIndexedSession(id)
+	return "session deleted", nil
+}
+
+// chatSessionContext returns the history window a new prompt in session id
+// should be sent with and the provider to use: providerID when given,
+// otherwise the session's.
+func (a *App) chatSessionContext(id string, providerID string) ([]ChatTurn, string, error) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	session, err := loadChatSession(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if providerID == "" {
+		providerID = session.ProviderID
+	}
+	return chatHistoryWindow(session.Messages), providerID, nil
+}
+
+// appendChatSessionTurns records an exchange, titling untitled sessions
+// after their first prompt. A non-empty providerID becomes the session's
+// provider.
+func (a *App) appendChatSessionTurns(id string, providerID string, turns ...ChatSessionMessage) error {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	session, err := loadChatSession(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	if session.Title == defaultChatSessionTitle && len(session.Messages) == 0 && len(turns) > 0 && turns[0].Role == "user" {
+		session.Title = chatSessionTitleFromPrompt(turns[0].Content)
+	}
+	if providerID != "" {
+		session.ProviderID = providerID
+	}
+	first := len(session.Messages)
+	for _, turn := range turns {
+		turn.CreatedAt = now
+		session.Messages = append(session.Messages, turn)
+	}
+	session.UpdatedAt = now
+	if err := saveChatSession(session); err != nil {
+		return err
+	}
+	for i := first; i < len(session.Messages); i++ {
+		a.chatIndex.indexChatMessage(session, i)
+	}
+	return nil
+}
+
+// chatHistoryWindow returns the most recent turns that fit within
+// chatHistoryMaxTurns and chatHistoryMaxChars, oldest first. Partial
+// answers are left out together with the prompts they answered.
+func chatHistoryWindow(messages []ChatSessionMessage) []ChatTurn {
+	var window []ChatTurn
+	total := 0
+	for i := len(messages) - 1; i >= 0 && len(window) < chatHistoryMaxTurns; i-- {
+		msg := messages[i]
+		if msg.Partial {
+			if i > 0 && messages[i-1].Role == "user" {
+				i--
+			}
+			continue
+		}
+		size := utf8.RuneCountInString(msg.Content)
+		if total+size > chatHistoryMaxChars {
+			break
+		}
+		total += size
+		window = append(window, ChatTurn{Role: msg.Role, Content: msg.Content})
+	}
+	for i, j := 0, len(window)-1; i < j; i, j = i+1, j-1 {
+		window[i], window[j] = window[j], window[i]
+	}
+	return window
+}
+
+func chatSessionTitleFromPrompt(prompt string) string {
+	title := strings.Join(strings.Fields(prompt), " ")
+	if utf8.RuneCountInString(title) > 30 {
+		title = string([]rune(title)[:30]) + "…"
+	}
+	if title == "" {
+		return defaultChatSessionTitle
+	}
+	return title
+}
+
+func (s ChatSession) summary() ChatSessionSummary {
+	return ChatSessionSummary{
+		ID:           s.ID,
+		Title:        s.Title,
+		ProviderID:   s.ProviderID,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		MessageCount: len(s.Messages),
+	}
+}
+
+func chatSessionsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "sessions"), nil
+}
+
+func listChatSessionIDs() ([]string, error) {
+	dir, err := chatSessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if ok && !entry.IsDir() && !strings.HasPrefix(id, ".") {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func chatSessionPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	dir, err := chatSessionsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+func loadChatSession(id string) (ChatSession, error) {
+	path, err := chatSessionPath(id)
+	if err != nil {
+		return ChatSession{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ChatSession{}, fmt.Errorf("session %s not found", id)
+	}
+	if err != nil {
+		return ChatSession{}, err
+	}
+	var session ChatSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return ChatSession{}, fmt.Errorf("session %s is corrupted: %w", id, err)
+	}
+	if session.Messages == nil {
+		session.Messages = []ChatSessionMessage{}
+	}
+	return session, nil
+}
+
+func saveChatSession(session ChatSession) error {
+	path, err := chatSessionPath(session.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
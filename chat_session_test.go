@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChatHistoryWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []ChatSessionMessage
+		want     []ChatTurn
+	}{
+		{
+			name: "keeps recent turns in order",
+			messages: []ChatSessionMessage{
+				{Role: "user", Content: "one"},
+				{Role: "assistant", Content: "two"},
+				{Role: "user", Content: "three"},
+			},
+			want: []ChatTurn{{"user", "one"}, {"assistant", "two"}, {"user", "three"}},
+		},
+		{
+			name: "leaves out partial answers and their prompts",
+			messages: []ChatSessionMessage{
+				{Role: "user", Content: "q1"},
+				{Role: "assistant", Content: "a1"},
+				{Role: "user", Content: "q2"},
+				{Role: "assistant", Content: "half an answ", Partial: true},
+				{Role: "user", Content: "q3"},
+				{Role: "assistant", Content: "a3"},
+			},
+			want: []ChatTurn{{"user", "q1"}, {"assistant", "a1"}, {"user", "q3"}, {"assistant", "a3"}},
+		},
+		{
+			name: "counts characters, not bytes",
+			messages: []ChatSessionMessage{
+				{Role: "user", Content: strings.Repeat("旧", chatHistoryMaxChars/2+1)},
+				{Role: "user", Content: strings.Repeat("中", chatHistoryMaxChars/2)},
+				{Role: "assistant", Content: strings.Repeat("文", chatHistoryMaxChars/2)},
+			},
+			want: []ChatTurn{
+				{"user", strings.Repeat("中", chatHistoryMaxChars/2)},
+				{"assistant", strings.Repeat("文", chatHistoryMaxChars/2)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chatHistoryWindow(tt.messages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("window = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var long []ChatSessionMessage
+	for i := 0; i < chatHistoryMaxTurns+5; i++ {
+		long = append(long, ChatSessionMessage{Role: "user", Content: "x"})
+	}
+	if got := len(chatHistoryWindow(long)); got != chatHistoryMaxTurns {
+		t.Errorf("window has %d turns, want %d", got, chatHistoryMaxTurns)
+	}
+}
+
+func TestPromptWithAttachmentNote(t *testing.T) {
+	attachments := []GeminiAttachment{
+		{Name: "config.json", Content: `{"log":{}}`},
+		{Name: "shot.png", Content: "iVBORw0KGgo=", IsBinary: true},
+		{Name: "empty.txt", Content: " "},
+	}
+	got := promptWithAttachmentNote("why?", attachments)
+	if want := "why?\n\n(Attachments: config.json, shot.png)"; got != want {
+		t.Errorf("note = %q, want %q", got, want)
+	}
+	if got := promptWithAttachmentNote("why?", nil); got != "why?" {
+		t.Errorf("note without attachments = %q", got)
+	}
+}
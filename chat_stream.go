@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var errChatCancelled = errors.New("chat cancelled")
+
+// ChatEvent is the payload of the chat:delta, chat:done and chat:error events.
+type ChatEvent struct {
+	RequestID string `json:"requestId"`
+	Delta     string `json:"delta,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+}
+
+// StreamChat starts a chat in the background and returns the request ID right
+// away. Output is delivered as chat:delta events, followed by a single
+// chat:done or chat:error. An empty requestID gets a generated one. With a
+// sessionID, recent turns are sent along as context and, once an answer (or
+// the partial answer of a cancelled or failed request, marked as such)
+// arrives, the prompt and answer are saved to that session; an empty
+// providerID then falls back to the session's provider, and otherwise to the
+// default provider. Attachments go to the model with this prompt only; the
+// session records just their names.
+func (a *App) StreamChat(requestID string, sessionID string, providerID string, prompt string, attachments []GeminiAttachment) (string, error) {
+	trimmed := strings.TrimSpace(prompt)
+	if trimmed == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	userTurn := ChatTurn{Role: "user", Content: buildPromptWithAttachments(trimmed, attachments)}
+	messages := []ChatTurn{userTurn}
+	sessionProvider := providerID
+	if sessionID != "" {
+		history, resolved, err := a.chatSessionContext(sessionID, providerID)
+		if err != nil {
+			return "", err
+		}
+		messages = append(history, userTurn)
+		sessionProvider = resolved
+	}
+	provider, err := a.resolveProvider(sessionProvider)
+	if err != nil {
+		return "", err
+	}
+
+	taskCtx, done := a.beginTask()
+	ctx, cancel := context.WithTimeout(taskCtx, 90*time.Second)
+	if err := a.registerChat(requestID, cancel); err != nil {
+		cancel()
+		done()
+		return "", err
+	}
+	go func() {
+		defer done()
+		defer a.finishChat(requestID)
+		output, completed := a.streamChat(ctx, requestID, provider, messages)
+		// The prompt is only saved with an answer, even a partial one, so a
+		// failed request does not leave a lone user turn in the history.
+		if sessionID != "" && strings.TrimSpace(output) != "" {
+			_ = a.appendChatSessionTurns(sessionID, providerID,
+				ChatSessionMessage{Role: "user", Content: promptWithAttachmentNote(trimmed, attachments)},
+				ChatSessionMessage{Role: "assistant", Content: output, Partial: !completed})
+		}
+	}()
+	return requestID, nil
+}
+
+// CancelChat stops an in-flight streamed chat; for the Gemini CLI this kills
+// its process group. The request ends with a chat:error event marked cancelled.
+func (a *App) CancelChat(requestID string) (string, error) {
+	a.chatMu.Lock()
+	cancel, ok := a.chats[requestID]
+	a.chatMu.Unlock()
+	if !ok {
+		return "chat is not running", nil
+	}
+	cancel()
+	return "chat cancelled", nil
+}
+
+func (a *App) registerChat(requestID string, cancel context.CancelFunc) error {
+	a.chatMu.Lock()
+	defer a.chatMu.Unlock()
+	if _, exists := a.chats[requestID]; exists {
+		return fmt.Errorf("chat %s is already running", requestID)
+	}
+	a.chats[requestID] = cancel
+	return nil
+}
+
+func (a *App) finishChat(requestID string) {
+	a.chatMu.Lock()
+	cancel, ok := a.chats[requestID]
+	delete(a.chats, requestID)
+	a.chatMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// streamChat runs one provider request, emitting chat events. It reports the
+// final output and whether the request completed.
+func (a *App) streamChat(ctx context.Context, requestID string, provider Provider, messages []ChatTurn) (string, bool) {
+	output, err := provider.Stream(ctx, messages, func(delta string) {
+		a.emitChatEvent("chat:delta", ChatEvent{RequestID: requestID, Delta: delta})
+	})
+	if err != nil {
+		cancelled := errors.Is(ctx.Err(), context.Canceled)
+		if cancelled {
+			err = errChatCancelled
+		}
+		a.emitChatEvent("chat:error", ChatEvent{
+			RequestID: requestID,
+			Content:   output,
+			Error:     err.Error(),
+			Cancelled: cancelled,
+		})
+		return output, false
+	}
+	a.emitChatEvent("chat:done", ChatEvent{RequestID: requestID, Content: output})
+	return output, true
+}
+
+func (a *App) emitChatEvent(name string, event ChatEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, name, event)
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
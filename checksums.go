@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// fetchReleaseFile downloads a single file from a release directory.
+func fetchReleaseFile(baseURL string, name string) ([]byte, error) {
+	url := strings.TrimRight(baseURL, "/") + "/" + name
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s download failed: %s", name, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return body, nil
+}
+
+// fetchChecksums downloads checksums.txt and its detached signature, and
+// only returns the checksums once the signature verifies against
+// updatePublicKey.
+func fetchChecksums(baseURL string) (string, error) {
+	checksums, err := fetchReleaseFile(baseURL, "checksums.txt")
+	if err != nil {
+		return "", err
+	}
+	signature, err := fetchReleaseFile(baseURL, "checksums.txt.sig")
+	if err != nil {
+		return "", fmt.Errorf("release is not signed: %w", err)
+	}
+	if err := verifyChecksumsSignature(checksums, string(signature), updatePublicKey); err != nil {
+		return "", err
+	}
+	return string(checksums), nil
+}
+
+// parseChecksums parses GoReleaser's checksums.txt ("<sha256>  <file>" per line)
+// into a map keyed by file name.
+func parseChecksums(content string) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary-mode entries with a leading '*'.
+		name := strings.TrimPrefix(fields[1], "*")
+		sums[name] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// verifyFileChecksum checks the file at path against the entry for fileName
+// in checksums.txt. A mismatching file is deleted so the next attempt
+// downloads it afresh instead of resuming a corrupt partial.
+func verifyFileChecksum(checksums string, fileName string, path string) error {
+	expected, ok := parseChecksums(checksums)[fileName]
+	if !ok {
+		return fmt.Errorf("no checksum for %s in checksums.txt, refusing to install", fileName)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", fileName, err)
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if actual != expected {
+		_ = os.Remove(path)
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s; the download may be corrupted or tampered with", fileName, expected, actual)
+	}
+	return nil
+}
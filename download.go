@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// A download is abandoned when no bytes arrive for downloadStallTimeout,
+	// however long the whole transfer takes.
+	downloadStallTimeout     = 30 * time.Second
+	downloadMaxAttempts      = 5
+	downloadProgressInterval = 250 * time.Millisecond
+)
+
+// errDownloadStalled is the cancel cause of an attempt that received nothing
+// for downloadStallTimeout.
+var errDownloadStalled = errors.New("download stalled")
+
+// downloadSlots holds one slot per destination path so concurrent downloads
+// of the same asset take turns instead of appending to the same .part file.
+var (
+	downloadSlotsMu sync.Mutex
+	downloadSlots   = make(map[string]chan struct{})
+)
+
+// DownloadProgress is the payload of update:progress events.
+type DownloadProgress struct {
+	File  string `json:"file"`
+	Bytes int64  `json:"bytes"`
+	// Total is -1 when the server does not report a length.
+	Total int64 `json:"total"`
+	// Rate is in bytes per second; EtaMs is -1 when unknown.
+	Rate  int64 `json:"rate"`
+	EtaMs int64 `json:"etaMs"`
+	Done  bool  `json:"done"`
+}
+
+func (a *App) emitDownloadProgress(progress DownloadProgress) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, "update:progress", progress)
+}
+
+func downloadCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "downloads"), nil
+}
+
+// downloadReleaseFile streams baseURL+fileName into the download cache and
+// returns the local path. A partial file left by an interrupted transfer,
+// whether earlier in this call or in a previous run, is resumed with an HTTP
+// Range request.
+func downloadReleaseFile(ctx context.Context, baseURL string, fileName string, onProgress func(DownloadProgress)) (string, error) {
+	dir, err := downloadCacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, fileName)
+	part := dest + ".part"
+	url := strings.TrimRight(baseURL, "/") + "/" + fileName
+
+	release, err := acquireDownloadSlot(ctx, dest)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	var lastErr error
+	for attempt := 1; attempt <= downloadMaxAttempts; attempt++ {
+		done, err := downloadAttempt(ctx, url, part, fileName, onProgress)
+		if done {
+			if err := os.Rename(part, dest); err != nil {
+				return "", err
+			}
+			return dest, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+		var statusErr *downloadStatusError
+		if errors.As(err, &statusErr) && statusErr.code < 500 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	return "", fmt.Errorf("failed to download %s: %w", fileName, lastErr)
+}
+
+// acquireDownloadSlot waits until no other download is writing dest.
+func acquireDownloadSlot(ctx context.Context, dest string) (func(), error) {
+	downloadSlotsMu.Lock()
+	slot, ok := downloadSlots[dest]
+	if !ok {
+		slot = make(chan struct{}, 1)
+		downloadSlots[dest] = slot
+	}
+	downloadSlotsMu.Unlock()
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type downloadStatusError struct {
+	code   int
+	status string
+}
+
+func (e *downloadStatusError) Error() string {
+	return "server returned " + e.status
+}
+
+// downloadAttempt appends to part from where it left off and reports whether
+// the file is complete.
+func downloadAttempt(ctx context.Context, url string, part string, fileName string, onProgress func(DownloadProgress)) (bool, error) {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stall := time.AfterFunc(downloadStallTimeout, func() { cancel(errDownloadStalled) })
+	defer stall.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: downloadStallTimeout,
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, downloadAttemptError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the Range header; start over.
+		offset = 0
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Already have everything, or the partial file is stale; the checksum
+		// check after download decides which.
+		if total := contentRangeTotal(resp.Header.Get("Content-Range")); total == offset {
+			return true, nil
+		}
+		_ = os.Remove(part)
+		return false, fmt.Errorf("partial download is stale: %s", resp.Status)
+	default:
+		return false, &downloadStatusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	file, err := os.OpenFile(part, flags, 0o600)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	tracker := &progressTracker{
+		file:     fileName,
+		bytes:    offset,
+		total:    total,
+		start:    time.Now(),
+		startPos: offset,
+		report:   onProgress,
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			stall.Reset(downloadStallTimeout)
+			if _, err := file.Write(buf[:n]); err != nil {
+				return false, err
+			}
+			tracker.add(int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return false, downloadAttemptError(ctx, readErr)
+		}
+	}
+	if total >= 0 && tracker.bytes != total {
+		return false, fmt.Errorf("download ended early at %d of %d bytes", tracker.bytes, total)
+	}
+	tracker.finish()
+	return true, nil
+}
+
+// downloadAttemptError reports a stall only when the stall timer cancelled
+// the attempt; any other cancellation, such as shutdown or the user, comes
+// back as the context's error.
+func downloadAttemptError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), errDownloadStalled) {
+		return fmt.Errorf("download stalled for %s", downloadStallTimeout)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func contentRangeTotal(header string) int64 {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+// progressTracker throttles progress reports and derives rate and ETA from
+// the bytes received in this attempt.
+type progressTracker struct {
+	file     string
+	bytes    int64
+	total    int64
+	start    time.Time
+	startPos int64
+	last     time.Time
+	report   func(DownloadProgress)
+}
+
+func (t *progressTracker) add(n int64) {
+	t.bytes += n
+	if time.Since(t.last) < downloadProgressInterval {
+		return
+	}
+	t.last = time.Now()
+	t.emit(false)
+}
+
+func (t *progressTracker) finish() {
+	t.emit(true)
+}
+
+func (t *progressTracker) emit(done bool) {
+	if t.report == nil {
+		return
+	}
+	progress := DownloadProgress{File: t.file, Bytes: t.bytes, Total: t.total, EtaMs: -1, Done: done}
+	if elapsed := time.Since(t.start).Seconds(); elapsed > 0 {
+		progress.Rate = int64(float64(t.bytes-t.startPos) / elapsed)
+	}
+	if progress.Rate > 0 && t.total >= 0 {
+		progress.EtaMs = (t.total - t.bytes) * 1000 / progress.Rate
+	}
+	if done {
+		progress.EtaMs = 0
+	}
+	t.report(progress)
+}
@@ -16,6 +16,8 @@ import {
     ToggleButton,
     Badge,
     Spinner,
+    Subtitle2,
+    Select,
 } from '@fluentui/react-components';
 import { Home24Regular } from '@fluentui/react-icons';
 import MonacoEditor from '@monaco-editor/react';
@@ -24,9 +26,9 @@ import Dashboard from './pages/Dashboard';
 import WorkBoard from './pages/WorkBoard';
 import ArticleEditor from './pages/ArticleEditor';
 import Pomodoro from './pages/Pomodoro';
-import { ChatMessage } from './components/ChatPanel';
+import { ChatMessage, ChatSessionOption, ProviderOption } from './components/ChatPanel';
 import { TodoItem } from './components/TodoList';
-import Settings, { AppSettings } from './pages/Settings';
+import Settings, { AppSettings, VlinkServiceStatus, VlinkSubscription } from './pages/Settings';
 
 type GeminiAttachment = {
     name: string;
@@ -34,6 +36,109 @@ type GeminiAttachment = {
     isBinary: boolean;
 };
 
+type VlinkState = {
+    state: 'starting' | 'running' | 'crashed' | 'backing-off' | 'stopped';
+    pid: number;
+    exitCode: number;
+    uptimeMs: number;
+    restarts: number;
+    retryInMs: number;
+    message: string;
+};
+
+const vlinkStateLabels: Record<VlinkState['state'], string> = {
+    starting: '启动中',
+    running: '运行中',
+    crashed: '已崩溃',
+    'backing-off': '等待重启',
+    stopped: '已停止',
+};
+
+type VlinkConfigDiagnostic = {
+    line: number;
+    column: number;
+    severity: 'error' | 'warning';
+    source: string;
+    path: string;
+    message: string;
+};
+
+type VlinkProfile = {
+    name: string;
+    active: boolean;
+    updatedAt: number;
+};
+
+type VlinkConfigVersion = {
+    id: string;
+    savedAt: number;
+    comment: string;
+    size: number;
+};
+
+type VlinkLogLine = {
+    seq: number;
+    time: number;
+    stream: string;
+    text: string;
+};
+
+const maxVlinkLogLines = 2000;
+
+type ExternalVlink = {
+    found: boolean;
+    pid: number;
+    name: string;
+    address: string;
+    source: string;
+    isVlink: boolean;
+};
+
+type ProxyDiagnosis = {
+    proxy: string;
+    ok: boolean;
+    stages: { name: string; ok: boolean; skipped: boolean; latencyMs: number; detail: string }[];
+};
+
+type UpdateStatus = {
+    currentVersion: string;
+    latestVersion: string;
+    available: boolean;
+    releaseNotes: { version: string; date: string; notes: string }[];
+};
+
+type DownloadProgress = {
+    file: string;
+    bytes: number;
+    total: number;
+    rate: number;
+    etaMs: number;
+    done: boolean;
+};
+
+const formatBytes = (value: number) => {
+    if (value >= 1024 * 1024) return `${(value / 1024 / 1024).toFixed(1)} MB`;
+    if (value >= 1024) return `${(value / 1024).toFixed(0)} KB`;
+    return `${value} B`;
+};
+
+const formatDownloadProgress = (progress: DownloadProgress) => {
+    if (progress.done) return `${progress.file} 下载完成，正在校验…`;
+    const received = progress.total > 0
+        ? `${formatBytes(progress.bytes)} / ${formatBytes(progress.total)} (${Math.floor((progress.bytes * 100) / progress.total)}%)`
+        : formatBytes(progress.bytes);
+    const eta = progress.etaMs >= 0 ? `，剩余约 ${Math.ceil(progress.etaMs / 1000)} 秒` : '';
+    return `正在下载 ${progress.file}：${received}，${formatBytes(progress.rate)}/s${eta}`;
+};
+
+type ChatEvent = {
+    requestId: string;
+    delta?: string;
+    content?: string;
+    error?: string;
+    cancelled?: boolean;
+};
+
 const metrics = [
     { label: '活跃技能', value: '32', trend: '+6%' },
     { label: '今日任务', value: '14', trend: '+2' },
@@ -169,10 +274,26 @@ const prepareAttachments = async (files: File[]): Promise<GeminiAttachment[]> =>
 
 export default function App() {
     const [messages, setMessages] = useState<ChatMessage[]>(starterMessages);
+    const [activeChatId, setActiveChatId] = useState('');
+    const [sessions, setSessions] = useState<ChatSessionOption[]>([]);
+    const [sessionId, setSessionId] = useState('');
+    const [providers, setProviders] = useState<ProviderOption[]>([]);
+    const [providerId, setProviderId] = useState('');
     const [inputValue, setInputValue] = useState('');
     const [pendingAttachments, setPendingAttachments] = useState<File[]>([]);
     const [isProxyEnabled, setIsProxyEnabled] = useState(false);
     const [vlinkStatus, setVlinkStatus] = useState<'idle' | 'ok' | 'error'>('idle');
+    const [vlinkState, setVlinkState] = useState<VlinkState | null>(null);
+    const [vlinkLogsOpen, setVlinkLogsOpen] = useState(false);
+    const [availableVersion, setAvailableVersion] = useState('');
+    const [vlinkLogLines, setVlinkLogLines] = useState<VlinkLogLine[]>([]);
+    const [vlinkLogPath, setVlinkLogPath] = useState('');
+    const [vlinkConflict, setVlinkConflict] = useState<ExternalVlink | null>(null);
+    const [vlinkConflictError, setVlinkConflictError] = useState('');
+    const [proxyDiagnoseOpen, setProxyDiagnoseOpen] = useState(false);
+    const [proxyDiagnosis, setProxyDiagnosis] = useState<ProxyDiagnosis | null>(null);
+    const [proxyDiagnoseError, setProxyDiagnoseError] = useState('');
+    const [proxyDiagnosing, setProxyDiagnosing] = useState(false);
     const [installViewActive, setInstallViewActive] = useState(false);
     const [installMessage, setInstallMessage] = useState('准备开始…');
 
@@ -182,6 +303,10 @@ export default function App() {
     const [updateOpen, setUpdateOpen] = useState(false);
     const [updateInProgress, setUpdateInProgress] = useState(false);
     const [updateResult, setUpdateResult] = useState('');
+    const [downloadProgress, setDownloadProgress] = useState('');
+    const [updateStatus, setUpdateStatus] = useState<UpdateStatus | null>(null);
+    const [updateCheckError, setUpdateCheckError] = useState('');
+    const [installedVersions, setInstalledVersions] = useState<{ version: string; savedAt: number }[]>([]);
 
     const [isDarkMode, setIsDarkMode] = useState(() => {
         if (typeof window === 'undefined' || !window.matchMedia) return true;
@@ -189,15 +314,19 @@ export default function App() {
     });
 
     const [installModalOpen, setInstallModalOpen] = useState(false);
-    const [vlinkPassword, setVlinkPassword] = useState('');
-    const [installResolve, setInstallResolve] = useState<
-        ((result: { confirmed: boolean; password: string }) => void) | null
-    >(null);
+    const [installResolve, setInstallResolve] = useState<((result: { confirmed: boolean }) => void) | null>(null);
     const [vlinkConfigOpen, setVlinkConfigOpen] = useState(false);
     const [vlinkConfigDraft, setVlinkConfigDraft] = useState('');
     const [vlinkConfigPath, setVlinkConfigPath] = useState('');
     const [vlinkConfigError, setVlinkConfigError] = useState('');
     const [vlinkConfigSaving, setVlinkConfigSaving] = useState(false);
+    const [vlinkConfigDiagnostics, setVlinkConfigDiagnostics] = useState<VlinkConfigDiagnostic[]>([]);
+    const [vlinkConfigComment, setVlinkConfigComment] = useState('');
+    const [vlinkConfigVersions, setVlinkConfigVersions] = useState<VlinkConfigVersion[]>([]);
+    const [vlinkConfigDiff, setVlinkConfigDiff] = useState('');
+    const [vlinkProfiles, setVlinkProfiles] = useState<VlinkProfile[]>([]);
+    const [vlinkProfileStatus, setVlinkProfileStatus] = useState('');
+    const [vlinkProfileName, setVlinkProfileName] = useState('');
     const [pendingVlinkStart, setPendingVlinkStart] = useState(false);
     const isWindows = useMemo(
         () => typeof navigator !== 'undefined' && /windows/i.test(navigator.userAgent),
@@ -207,6 +336,9 @@ export default function App() {
     const [activeView, setActiveView] = useState<'home' | 'settings' | 'board' | 'editor' | 'pomodoro'>('home');
     const [settingsDraft, setSettingsDraft] = useState<AppSettings | null>(null);
     const [settingsError, setSettingsError] = useState('');
+    const [vlinkService, setVlinkService] = useState<VlinkServiceStatus | null>(null);
+    const [subscriptions, setSubscriptions] = useState<VlinkSubscription[]>([]);
+    const [subscriptionBusy, setSubscriptionBusy] = useState(false);
     const [todos, setTodos] = useState<TodoItem[]>(starterTodos);
     const [articleDraft, setArticleDraft] = useState(
         '# 今日协同计划\n\n- 目标一：统一跨部门排期\n- 目标二：完善自动化告警\n\n## 关键动作\n\n1. 明确责任人\n2. 完成风险评估\n3. 输出复盘清单\n\n> 支持 **Markdown** 与任务清单。\n'
@@ -219,6 +351,18 @@ export default function App() {
         notes: '',
         pomodoroNotifyDesktop: true,
         pomodoroNotifySound: false,
+        downloadMirror: '',
+        providers: [],
+        defaultProvider: 'gemini',
+        updateChannel: 'stable',
+        activeVlinkProfile: '',
+        proxyMode: 'vlink',
+        proxyUrl: '',
+        vlinkSocksPort: 0,
+        vlinkHttpPort: 0,
+        proxyProbeUrls: [],
+        vlinkRunMode: 'process',
+        vlinkSubscriptionRefreshHours: 24,
     };
 
     const currentSettings = settingsDraft ?? fallbackSettings;
@@ -226,6 +370,8 @@ export default function App() {
     const chatBodyRef = useRef<HTMLDivElement | null>(null);
     const fileInputRef = useRef<HTMLInputElement | null>(null);
     const vlinkTimerRef = useRef<number | null>(null);
+    const vlinkEditorRef = useRef<any>(null);
+    const monacoRef = useRef<any>(null);
 
     useEffect(() => {
         if (chatBodyRef.current) {
@@ -233,6 +379,58 @@ export default function App() {
         }
     }, [messages]);
 
+    const loadSessions = async () => {
+        try {
+            const list = (await window.go.main.App.ListChatSessions()) || [];
+            setSessions(list);
+            return list;
+        } catch {
+            return [];
+        }
+    };
+
+    const openSession = async (id: string) => {
+        try {
+            const session = await window.go.main.App.LoadChatSession(id);
+            setSessionId(session.id);
+            setMessages(
+                session.messages.length
+                    ? session.messages.map((msg) => ({
+                          role: msg.role as ChatMessage['role'],
+                          content: msg.partial ? `${msg.content}\n（已停止）` : msg.content,
+                      }))
+                    : starterMessages
+            );
+            if (session.providerId) {
+                setProviderId(session.providerId);
+            }
+        } catch {
+            setSessionId('');
+            setMessages(starterMessages);
+        }
+    };
+
+    const restoreLatestSession = async () => {
+        const list = await loadSessions();
+        if (list.length > 0) {
+            await openSession(list[0].id);
+        }
+    };
+
+    const loadProviders = async () => {
+        try {
+            const list = (await window.go.main.App.ListProviders()) || [];
+            setProviders(list);
+            setProviderId((current) =>
+                list.some((provider) => provider.id === current)
+                    ? current
+                    : list.find((provider) => provider.default)?.id ?? list[0]?.id ?? ''
+            );
+        } catch {
+            setProviders([]);
+        }
+    };
+
     const loadSettings = async () => {
         try {
             const current = await window.go.main.App.GetSettings();
@@ -276,6 +474,54 @@ export default function App() {
             }
         });
 
+        EventsOn('vlink:state', (payload: VlinkState) => {
+            setVlinkState(payload);
+            if (payload.state === 'running') {
+                setIsProxyEnabled(true);
+                startVlinkPolling();
+            } else if (payload.state === 'stopped') {
+                setIsProxyEnabled(false);
+                stopVlinkPolling();
+            }
+        });
+
+        EventsOn('vlink:profile', (status: { profile: string; stage: string; message: string; savedAs?: string }) => {
+            setVlinkProfileStatus(status.stage === 'done' && !status.savedAs ? '' : status.message);
+        });
+
+        EventsOn('update:available', (status: { currentVersion: string; latestVersion: string }) => {
+            setAvailableVersion(status?.latestVersion ?? '');
+        });
+
+        EventsOn('update:progress', (progress: DownloadProgress) => {
+            setDownloadProgress(formatDownloadProgress(progress));
+        });
+
+        EventsOn('vlink:log', (line: VlinkLogLine) => {
+            setVlinkLogLines((prev) => [...prev, line].slice(-maxVlinkLogLines));
+        });
+
+        EventsOn('menu:vlink-logs', async () => {
+            try {
+                const page = await window.go.main.App.GetVlinkLogs(0, maxVlinkLogLines);
+                setVlinkLogLines(page?.lines ?? []);
+                setVlinkLogPath(page?.path ?? '');
+            } catch {
+                setVlinkLogLines([]);
+            }
+            setVlinkLogsOpen(true);
+        });
+
+        EventsOn('vlink:conflict', (payload: ExternalVlink) => {
+            setVlinkConflictError('');
+            setVlinkConflict(payload);
+        });
+
+        EventsOn('menu:diagnose-proxy', () => {
+            setProxyDiagnoseOpen(true);
+            runProxyDiagnosis();
+        });
+
         EventsOn('vlink:config', (payload: { path?: string; content?: string }) => {
             setVlinkConfigError('');
             setVlinkConfigPath(payload?.path ?? '');
@@ -284,6 +530,50 @@ export default function App() {
             setPendingVlinkStart(true);
         });
 
+        EventsOn('chat:delta', (payload: ChatEvent) => {
+            setMessages((prev) =>
+                prev.map((msg) =>
+                    msg.id === payload.requestId
+                        ? { ...msg, content: (msg.pending ? '' : msg.content) + (payload.delta ?? ''), pending: false }
+                        : msg
+                )
+            );
+        });
+
+        EventsOn('chat:done', (payload: ChatEvent) => {
+            setActiveChatId((current) => (current === payload.requestId ? '' : current));
+            loadSessions();
+            setMessages((prev) =>
+                prev.map((msg) =>
+                    msg.id === payload.requestId
+                        ? { ...msg, content: payload.content || '（无返回）', pending: false }
+                        : msg
+                )
+            );
+        });
+
+        EventsOn('chat:error', (payload: ChatEvent) => {
+            setActiveChatId((current) => (current === payload.requestId ? '' : current));
+            loadSessions();
+            setMessages((prev) =>
+                prev.map((msg) =>
+                    msg.id === payload.requestId
+                        ? {
+                              ...msg,
+                              content: payload.cancelled
+                                  ? `${payload.content || ''}\n（已停止）`.trim()
+                                  : `调用模型失败：${payload.error || '请检查模型服务配置。'}`,
+                              pending: false,
+                          }
+                        : msg
+                )
+            );
+        });
+
+        EventsOn('vlink:subscription', (subscription: VlinkSubscription) => {
+            setSubscriptions((prev) => prev.map((item) => (item.id === subscription.id ? subscription : item)));
+        });
+
         EventsOn('menu:about', async () => {
             try {
                 const info = await window.go.main.App.About();
@@ -295,24 +585,75 @@ export default function App() {
         });
 
         EventsOn('menu:update', () => {
-            setUpdateResult('');
-            setUpdateInProgress(false);
-            setUpdateOpen(true);
+            openUpdateDialog();
         });
 
         EventsOn('menu:settings', async () => {
             setSettingsError('');
             await loadSettings();
+            loadVlinkService();
+            loadSubscriptions();
             setActiveView('settings');
         });
 
         loadSettings();
+        loadProviders();
+        loadVlinkProfiles();
+        restoreLatestSession();
+        window.go.main.App.GetVlinkState()
+            .then((state) => {
+                setVlinkState(state as VlinkState);
+                if (state.state === 'running') {
+                    setIsProxyEnabled(true);
+                    startVlinkPolling();
+                }
+            })
+            .catch(() => undefined);
     }, []);
 
-    const handleNewSession = () => {
+    const handleNewSession = async () => {
         setMessages(starterMessages);
         setPendingAttachments([]);
         setInputValue('');
+        try {
+            const created = await window.go.main.App.CreateChatSession('');
+            setSessionId(created.id);
+            await loadSessions();
+        } catch {
+            setSessionId('');
+        }
+    };
+
+    const handleRenameSession = async (id: string, title: string) => {
+        try {
+            await window.go.main.App.RenameChatSession(id, title);
+        } finally {
+            await loadSessions();
+        }
+    };
+
+    const handleDeleteSession = async (id: string) => {
+        try {
+            await window.go.main.App.DeleteChatSession(id);
+        } catch {
+            return;
+        }
+        const list = await loadSessions();
+        if (list.length > 0) {
+            await openSession(list[0].id);
+        } else {
+            setSessionId('');
+            setMessages(starterMessages);
+        }
+    };
+
+    const handleStop = async () => {
+        if (!activeChatId) return;
+        try {
+            await window.go.main.App.CancelChat(activeChatId);
+        } catch {
+            setActiveChatId('');
+        }
     };
 
     const handleSend = async () => {
@@ -324,7 +665,7 @@ export default function App() {
         setMessages((prev) => [
             ...prev,
             { role: 'user', content: userText },
-            { role: 'assistant', content: '处理中…', id: assistantId },
+            { role: 'assistant', content: '处理中…', id: assistantId, pending: true },
         ]);
         setInputValue('');
 
@@ -334,13 +675,17 @@ export default function App() {
         try {
             const attachments = await prepareAttachments(filesToSend);
             const prompt = trimmed || '请根据附件内容进行分析。';
-            const response = attachments.length
-                ? await window.go.main.App.ChatWithGeminiWithAttachments(prompt, attachments)
-                : await window.go.main.App.ChatWithGemini(prompt);
-            setMessages((prev) =>
-                prev.map((msg) => (msg.id === assistantId ? { ...msg, content: response || '（无返回）' } : msg))
-            );
+            let targetSession = sessionId;
+            if (!targetSession) {
+                const created = await window.go.main.App.CreateChatSession('');
+                targetSession = created.id;
+                setSessionId(targetSession);
+            }
+            setActiveChatId(assistantId);
+            await window.go.main.App.StreamChat(assistantId, targetSession, providerId, prompt, attachments);
+            loadSessions();
         } catch (error) {
+            setActiveChatId((current) => (current === assistantId ? '' : current));
             setMessages((prev) =>
                 prev.map((msg) =>
                     msg.id === assistantId
@@ -384,8 +729,7 @@ export default function App() {
     };
 
     const requestInstallVlink = () =>
-        new Promise<{ confirmed: boolean; password: string }>((resolve) => {
-            setVlinkPassword('');
+        new Promise<{ confirmed: boolean }>((resolve) => {
             setInstallModalOpen(true);
             setInstallResolve(() => resolve);
         });
@@ -400,13 +744,123 @@ export default function App() {
             setVlinkConfigError('');
             setVlinkConfigPath(config?.path ?? '');
             setVlinkConfigDraft(config?.content ?? '');
+            setVlinkConfigComment('');
+            setVlinkConfigDiff('');
             setVlinkConfigOpen(true);
+            await loadVlinkConfigVersions();
         } catch {
             setVlinkConfigError('配置加载失败，请稍后重试。');
             setVlinkConfigOpen(true);
         }
     };
 
+    const loadVlinkProfiles = async () => {
+        try {
+            const profiles = await window.go.main.App.ListVlinkProfiles();
+            setVlinkProfiles(profiles ?? []);
+        } catch {
+            setVlinkProfiles([]);
+        }
+    };
+
+    const handleVlinkProfileSwitch = async (name: string) => {
+        if (!name) return;
+        try {
+            await window.go.main.App.SwitchVlinkProfile(name);
+            setSettingsDraft((prev) => (prev ? { ...prev, activeVlinkProfile: name } : prev));
+        } catch (e) {
+            setVlinkProfileStatus(`切换失败: ${e}`);
+        } finally {
+            await loadVlinkProfiles();
+        }
+    };
+
+    const handleVlinkProfileSave = async () => {
+        const name = vlinkProfileName.trim();
+        if (!name) return;
+        setVlinkConfigError('');
+        try {
+            const result = await window.go.main.App.SaveVlinkProfile(name, vlinkConfigDraft);
+            showVlinkConfigDiagnostics(result?.diagnostics ?? []);
+            if (!result?.saved) {
+                setVlinkConfigError('配置有错误，未保存为配置档。');
+                return;
+            }
+            setVlinkProfileName('');
+            await loadVlinkProfiles();
+        } catch (e) {
+            setVlinkConfigError(`配置档保存失败: ${e}`);
+        }
+    };
+
+    const loadVlinkConfigVersions = async () => {
+        try {
+            const versions = await window.go.main.App.ListVlinkConfigVersions();
+            setVlinkConfigVersions(versions ?? []);
+        } catch {
+            setVlinkConfigVersions([]);
+        }
+    };
+
+    const handleVlinkConfigDiff = async (id: string) => {
+        try {
+            const diff = await window.go.main.App.DiffVlinkConfig(id, 'current');
+            setVlinkConfigDiff(diff || '与当前配置相同');
+        } catch (e) {
+            setVlinkConfigDiff(`对比失败: ${e}`);
+        }
+    };
+
+    const handleVlinkConfigRestore = async (id: string) => {
+        setVlinkConfigError('');
+        try {
+            const result = await window.go.main.App.RestoreVlinkConfig(id);
+            showVlinkConfigDiagnostics(result?.diagnostics ?? []);
+            if (!result?.saved) {
+                setVlinkConfigError('该版本未通过校验，未恢复。');
+                return;
+            }
+            const config = await window.go.main.App.GetVlinkConfig();
+            setVlinkConfigDraft(config?.content ?? '');
+            setVlinkConfigDiff('');
+            await loadVlinkConfigVersions();
+        } catch {
+            setVlinkConfigError('恢复失败，请稍后重试。');
+        }
+    };
+
+    const showVlinkConfigDiagnostics = (diagnostics: VlinkConfigDiagnostic[]) => {
+        setVlinkConfigDiagnostics(diagnostics);
+        const model = vlinkEditorRef.current?.getModel();
+        const monaco = monacoRef.current;
+        if (!model || !monaco) return;
+        monaco.editor.setModelMarkers(
+            model,
+            'vlink-config',
+            diagnostics.map((item) => ({
+                startLineNumber: item.line,
+                startColumn: item.column,
+                endLineNumber: item.line,
+                endColumn: model.getLineMaxColumn(item.line),
+                message: item.message,
+                severity: item.severity === 'error' ? monaco.MarkerSeverity.Error : monaco.MarkerSeverity.Warning,
+            }))
+        );
+    };
+
+    useEffect(() => {
+        if (!vlinkConfigOpen) return;
+        const timer = window.setTimeout(async () => {
+            try {
+                const result = await window.go.main.App.ValidateVlinkConfig(vlinkConfigDraft, false);
+                showVlinkConfigDiagnostics(result?.diagnostics ?? []);
+            } catch {
+                // Validation is advisory while typing; saving validates again.
+            }
+        }, 400);
+        return () => window.clearTimeout(timer);
+    }, [vlinkConfigDraft, vlinkConfigOpen]);
+
     const handleVlinkConfigSave = async () => {
         const appApi = window.go?.main?.App;
         if (!appApi || typeof appApi.SaveVlinkConfig !== 'function') {
@@ -416,7 +870,13 @@ export default function App() {
         setVlinkConfigSaving(true);
         setVlinkConfigError('');
         try {
-            await appApi.SaveVlinkConfig(vlinkConfigDraft);
+            const result = await appApi.SaveVlinkConfig(vlinkConfigDraft, vlinkConfigComment);
+            showVlinkConfigDiagnostics(result?.diagnostics ?? []);
+            if (!result?.saved) {
+                const errors = (result?.diagnostics ?? []).filter((item) => item.severity === 'error');
+                setVlinkConfigError(`配置有 ${errors.length} 处错误，请修正后再保存。`);
+                return;
+            }
             setVlinkConfigOpen(false);
             if (pendingVlinkStart) {
                 setPendingVlinkStart(false);
@@ -465,9 +925,10 @@ export default function App() {
                         stopVlinkPolling();
                         return;
                     }
+                    setDownloadProgress('');
                     setInstallViewActive(true);
                     setInstallMessage('准备安装 vlink…');
-                    await appApi.InstallVlink('', result.password);
+                    await appApi.InstallVlink('');
                 }
 
                 try {
@@ -476,6 +937,11 @@ export default function App() {
                     setIsProxyEnabled(true);
                 } catch (startError) {
                     const message = String(startError?.message || startError || '');
+                    if (message.includes('vlink conflict')) {
+                        // The vlink:conflict dialog takes it from here.
+                        stopVlinkPolling();
+                        return;
+                    }
                     const needsConfig = message.includes('vlink config');
                     const shouldPromptInstall = message.includes('no such file') || message.includes('vlink');
                     if (needsConfig) {
@@ -489,9 +955,10 @@ export default function App() {
                             stopVlinkPolling();
                             return;
                         }
+                        setDownloadProgress('');
                         setInstallViewActive(true);
                         setInstallMessage('准备安装 vlink…');
-                        await appApi.InstallVlink('', result.password);
+                        await appApi.InstallVlink('');
                         await appApi.StartVlink();
                         startVlinkPolling();
                         setIsProxyEnabled(true);
@@ -509,11 +976,33 @@ export default function App() {
         }
     };
 
+    const openUpdateDialog = async () => {
+        setUpdateResult('');
+        setUpdateInProgress(false);
+        setUpdateStatus(null);
+        setUpdateCheckError('');
+        setUpdateOpen(true);
+        try {
+            const versions = await window.go.main.App.ListInstalledVersions();
+            setInstalledVersions(versions ?? []);
+        } catch {
+            setInstalledVersions([]);
+        }
+        try {
+            const status = await window.go.main.App.CheckForUpdate();
+            setUpdateStatus(status);
+        } catch (e) {
+            setUpdateCheckError(`检查更新失败: ${e}`);
+        }
+    };
+
     const handleUpdateConfirm = async () => {
+        if (!updateStatus?.available) return;
         setUpdateInProgress(true);
-        setUpdateResult('正在检查和更新，请稍候...');
+        setUpdateResult(`正在更新到 ${updateStatus.latestVersion}，请稍候...`);
+        setDownloadProgress('');
         try {
-            const result = await window.go.main.App.SelfUpdate();
+            const result = await window.go.main.App.SelfUpdateFromArchive(updateStatus.latestVersion);
             setUpdateResult(result);
         } catch (e) {
             setUpdateResult(`更新失败: ${e}`);
@@ -522,16 +1011,143 @@ export default function App() {
         }
     };
 
+    const handleAdoptVlink = async () => {
+        setVlinkConflictError('');
+        try {
+            await window.go.main.App.AdoptVlink();
+            setVlinkConflict(null);
+            startVlinkPolling();
+            setIsProxyEnabled(true);
+        } catch (err) {
+            setVlinkConflictError(String(err));
+        }
+    };
+
+    const handleReplaceExternalVlink = async () => {
+        setVlinkConflictError('');
+        try {
+            await window.go.main.App.StopExternalVlink();
+            await window.go.main.App.StartVlink();
+            setVlinkConflict(null);
+            startVlinkPolling();
+            setIsProxyEnabled(true);
+        } catch (err) {
+            setVlinkConflictError(String(err));
+        }
+    };
+
+    const runProxyDiagnosis = async () => {
+        setProxyDiagnosing(true);
+        setProxyDiagnoseError('');
+        try {
+            setProxyDiagnosis(await window.go.main.App.DiagnoseProxy());
+        } catch (err) {
+            setProxyDiagnosis(null);
+            setProxyDiagnoseError(String(err));
+        } finally {
+            setProxyDiagnosing(false);
+        }
+    };
+
+    const handleRollback = async (version: string) => {
+        setUpdateInProgress(true);
+        setUpdateResult(`正在回滚到 ${version}...`);
+        try {
+            const result = await window.go.main.App.RollbackTo(version);
+            setUpdateResult(result);
+        } catch (e) {
+            setUpdateResult(`回滚失败: ${e}`);
+        } finally {
+            setUpdateInProgress(false);
+        }
+    };
+
     const handleSettingsSave = async () => {
         if (!settingsDraft) return;
         setSettingsError('');
         try {
             await window.go.main.App.SaveSettings(settingsDraft);
+            await loadProviders();
         } catch {
             setSettingsError('设置保存失败');
         }
     };
 
+    const loadVlinkService = async () => {
+        try {
+            setVlinkService(await window.go.main.App.GetVlinkServiceStatus());
+        } catch {
+            setVlinkService(null);
+        }
+    };
+
+    const handleVlinkServiceEnable = async (enabled: boolean) => {
+        setSettingsError('');
+        try {
+            setVlinkService(await window.go.main.App.InstallVlinkService(enabled));
+        } catch (err) {
+            setSettingsError(`vlink 服务设置失败：${String(err)}`);
+        }
+    };
+
+    const handleVlinkServiceUninstall = async () => {
+        setSettingsError('');
+        try {
+            await window.go.main.App.UninstallVlinkService();
+        } catch (err) {
+            setSettingsError(`vlink 服务卸载失败：${String(err)}`);
+        }
+        await loadVlinkService();
+    };
+
+    const loadSubscriptions = async () => {
+        try {
+            setSubscriptions(await window.go.main.App.ListVlinkSubscriptions());
+        } catch {
+            setSubscriptions([]);
+        }
+    };
+
+    const handleSubscriptionImport = async (url: string) => {
+        setSettingsError('');
+        setSubscriptionBusy(true);
+        try {
+            await window.go.main.App.ImportVlinkSubscription(url);
+            return true;
+        } catch (err) {
+            setSettingsError(`订阅导入失败：${String(err)}`);
+            return false;
+        } finally {
+            setSubscriptionBusy(false);
+            await loadSubscriptions();
+        }
+    };
+
+    const handleSubscriptionRefresh = async () => {
+        setSettingsError('');
+        setSubscriptionBusy(true);
+        try {
+            setSubscriptions(await window.go.main.App.RefreshVlinkSubscriptions());
+        } catch (err) {
+            setSettingsError(`订阅更新失败：${String(err)}`);
+        } finally {
+            setSubscriptionBusy(false);
+        }
+    };
+
+    const handleSubscriptionRemove = async (id: string) => {
+        setSettingsError('');
+        setSubscriptionBusy(true);
+        try {
+            await window.go.main.App.RemoveVlinkSubscription(id);
+        } catch (err) {
+            setSettingsError(`订阅删除失败：${String(err)}`);
+        } finally {
+            setSubscriptionBusy(false);
+            await loadSubscriptions();
+        }
+    };
+
     const updateSettingsDraft = (updater: (prev: AppSettings) => AppSettings) => {
         setSettingsDraft((prev) => updater(prev ?? fallbackSettings));
     };
@@ -575,13 +1191,46 @@ export default function App() {
                         <div className="domour-status">
                             <Badge appearance="filled" color="brand">Cosmos-Star 在线</Badge>
                             <Badge appearance="outline">本地模式</Badge>
+                            {availableVersion && (
+                                <Button
+                                    appearance="subtle"
+                                    size="small"
+                                    onClick={openUpdateDialog}
+                                >
+                                    新版本 {availableVersion}
+                                </Button>
+                            )}
+                            {vlinkProfiles.length > 0 && (
+                                <Select
+                                    size="small"
+                                    aria-label="vlink 配置档"
+                                    value={vlinkProfiles.find((item) => item.active)?.name ?? ''}
+                                    onChange={(_, data) => handleVlinkProfileSwitch(data.value)}
+                                    title={vlinkProfileStatus || undefined}
+                                >
+                                    {!vlinkProfiles.some((item) => item.active) && <option value="">选择配置档</option>}
+                                    {vlinkProfiles.map((item) => (
+                                        <option key={item.name} value={item.name}>
+                                            {item.name}
+                                        </option>
+                                    ))}
+                                </Select>
+                            )}
+                            {vlinkProfileStatus && <Caption1>{vlinkProfileStatus}</Caption1>}
                             <ToggleButton
                                 checked={isProxyEnabled}
                                 onClick={handleVlinkToggle}
                                 appearance="subtle"
                             >
                                 网络加速
-                                <span className={`status-dot ${vlinkStatus}`} title={vlinkStatus === 'ok' ? '正常' : vlinkStatus === 'error' ? '异常' : '未启动'} />
+                                <span
+                                    className={`status-dot ${vlinkStatus}`}
+                                    title={
+                                        vlinkState && vlinkState.state !== 'running'
+                                            ? `${vlinkStateLabels[vlinkState.state]}${vlinkState.message ? `：${vlinkState.message}` : ''}`
+                                            : vlinkStatus === 'ok' ? '正常' : vlinkStatus === 'error' ? '异常' : '未启动'
+                                    }
+                                />
                             </ToggleButton>
                         </div>
                     </header>
@@ -612,6 +1261,14 @@ export default function App() {
                             onBack={() => setActiveView('home')}
                             onSave={handleSettingsSave}
                             onUpdate={updateSettingsDraft}
+                            vlinkService={vlinkService}
+                            onVlinkServiceEnable={handleVlinkServiceEnable}
+                            onVlinkServiceUninstall={handleVlinkServiceUninstall}
+                            subscriptions={subscriptions}
+                            subscriptionBusy={subscriptionBusy}
+                            onSubscriptionImport={handleSubscriptionImport}
+                            onSubscriptionRefresh={handleSubscriptionRefresh}
+                            onSubscriptionRemove={handleSubscriptionRemove}
                         />
                     ) : activeView === 'board' ? (
                         <WorkBoard items={todos} onBack={() => setActiveView('home')} />
@@ -633,10 +1290,21 @@ export default function App() {
                             activityFeed={activityFeed}
                             messages={messages}
                             onNewSession={handleNewSession}
+                            sessions={sessions}
+                            sessionId={sessionId}
+                            onSelectSession={openSession}
+                            onRenameSession={handleRenameSession}
+                            onDeleteSession={handleDeleteSession}
+                            onSearch={async (query) => (await window.go.main.App.SearchChats(query)) || []}
+                            providers={providers}
+                            providerId={providerId}
+                            onProviderChange={setProviderId}
                             inputValue={inputValue}
                             onInputChange={setInputValue}
                             onInputKeyDown={handleInputKeyDown}
                             onSend={handleSend}
+                            streaming={Boolean(activeChatId)}
+                            onStop={handleStop}
                             pendingAttachments={pendingAttachments}
                             onAttachFiles={handleAttachFiles}
                             onRemoveAttachment={removeAttachment}
@@ -658,6 +1326,7 @@ export default function App() {
                         <div className="install-title">正在安装 vlink</div>
                         <div className="install-subtitle">请保持窗口开启，安装完成后会自动返回首页。</div>
                         <div className="install-progress" id="installProgress">{installMessage}</div>
+                        {downloadProgress && <div className="install-progress">{downloadProgress}</div>}
                     </div>
                 </div>
             )}
@@ -668,20 +1337,11 @@ export default function App() {
                         <DialogTitle>安装 vlink</DialogTitle>
                         <DialogContent>
                             <Body1>未检测到 vlink，是否自动下载安装？</Body1>
-                            {!isWindows ? (
+                            {!isWindows && (
                                 <div className="modal-field">
-                                    <Caption1>sudo 密码</Caption1>
-                                    <Input
-                                        type="password"
-                                        value={vlinkPassword}
-                                        onChange={(event) => setVlinkPassword(event.target.value)}
-                                        placeholder="请输入 sudo 密码"
-                                    />
-                                    <Caption1>密码仅用于本次安装，不会保存。</Caption1>
-                                </div>
-                            ) : (
-                                <div className="modal-field">
-                                    <Caption1>Windows 无需 sudo 密码。</Caption1>
+                                    <Caption1>
+                                        安装到 /usr/local/bin 时系统会弹出授权窗口；无法授权时将安装到 ~/.local/bin。
+                                    </Caption1>
                                 </div>
                             )}
                         </DialogContent>
@@ -690,7 +1350,7 @@ export default function App() {
                                 appearance="secondary"
                                 onClick={() => {
                                     setInstallModalOpen(false);
-                                    if (installResolve) installResolve({ confirmed: false, password: '' });
+                                    if (installResolve) installResolve({ confirmed: false });
                                 }}
                             >
                                 取消
@@ -699,8 +1359,7 @@ export default function App() {
                                 appearance="primary"
                                 onClick={() => {
                                     setInstallModalOpen(false);
-                                    const trimmed = vlinkPassword.trim();
-                                    if (installResolve) installResolve({ confirmed: isWindows || trimmed.length > 0, password: trimmed });
+                                    if (installResolve) installResolve({ confirmed: true });
                                 }}
                             >
                                 开始安装
@@ -726,6 +1385,10 @@ export default function App() {
                                     <MonacoEditor
                                         value={vlinkConfigDraft}
                                         onChange={(value) => setVlinkConfigDraft(value ?? '')}
+                                        onMount={(editor, monaco) => {
+                                            vlinkEditorRef.current = editor;
+                                            monacoRef.current = monaco;
+                                        }}
                                         language="json"
                                         theme={isDarkMode ? 'vs-dark' : 'vs'}
                                         options={{
@@ -743,6 +1406,56 @@ export default function App() {
                                     />
                                 </div>
                             </div>
+                            <div className="modal-field">
+                                <Caption1>另存为配置档</Caption1>
+                                <div className="vlink-profile-save">
+                                    <Input
+                                        value={vlinkProfileName}
+                                        onChange={(event) => setVlinkProfileName(event.target.value)}
+                                        placeholder="例如：office、home"
+                                    />
+                                    <Button onClick={handleVlinkProfileSave} disabled={!vlinkProfileName.trim()}>
+                                        保存配置档
+                                    </Button>
+                                </div>
+                            </div>
+                            <div className="modal-field">
+                                <Caption1>保存备注（可选）</Caption1>
+                                <Input
+                                    value={vlinkConfigComment}
+                                    onChange={(event) => setVlinkConfigComment(event.target.value)}
+                                    placeholder="例如：更换节点"
+                                />
+                            </div>
+                            {vlinkConfigVersions.length > 0 && (
+                                <div className="version-history">
+                                    <Caption1>历史版本</Caption1>
+                                    {vlinkConfigVersions.map((item) => (
+                                        <div key={item.id} className="version-history-row">
+                                            <Body1>{new Date(item.savedAt).toLocaleString()}</Body1>
+                                            <Caption1>{item.comment}</Caption1>
+                                            <Button size="small" appearance="subtle" onClick={() => handleVlinkConfigDiff(item.id)}>
+                                                对比
+                                            </Button>
+                                            <Button size="small" appearance="subtle" onClick={() => handleVlinkConfigRestore(item.id)}>
+                                                恢复
+                                            </Button>
+                                        </div>
+                                    ))}
+                                </div>
+                            )}
+                            {vlinkConfigDiff && <pre className="vlink-config-diff">{vlinkConfigDiff}</pre>}
+                            {vlinkConfigDiagnostics.length > 0 && (
+                                <ul className="vlink-config-diagnostics">
+                                    {vlinkConfigDiagnostics.map((item, index) => (
+                                        <li key={index} className={`is-${item.severity}`}>
+                                            <Caption1>
+                                                {item.line}:{item.column} {item.message}
+                                            </Caption1>
+                                        </li>
+                                    ))}
+                                </ul>
+                            )}
                             {vlinkConfigError && <Caption1>{vlinkConfigError}</Caption1>}
                         </DialogContent>
                         <DialogActions>
@@ -771,6 +1484,102 @@ export default function App() {
                 </DialogSurface>
             </Dialog>
 
+            <Dialog open={vlinkLogsOpen} onOpenChange={(_, data) => setVlinkLogsOpen(data.open)}>
+                <DialogSurface className="vlink-logs-dialog">
+                    <DialogBody>
+                        <DialogTitle>vlink 日志</DialogTitle>
+                        <DialogContent>
+                            {vlinkLogPath && <Caption1>日志文件：{vlinkLogPath}</Caption1>}
+                            <pre className="vlink-logs">
+                                {vlinkLogLines.length === 0
+                                    ? '暂无日志'
+                                    : vlinkLogLines
+                                          .map(
+                                              (line) =>
+                                                  `${new Date(line.time).toLocaleTimeString()} [${line.stream}] ${line.text}`
+                                          )
+                                          .join('\n')}
+                            </pre>
+                        </DialogContent>
+                        <DialogActions>
+                            <Button appearance="primary" onClick={() => setVlinkLogsOpen(false)}>关闭</Button>
+                        </DialogActions>
+                    </DialogBody>
+                </DialogSurface>
+            </Dialog>
+
+            <Dialog open={vlinkConflict !== null} onOpenChange={(_, data) => !data.open && setVlinkConflict(null)}>
+                <DialogSurface>
+                    <DialogBody>
+                        <DialogTitle>vlink 已在运行</DialogTitle>
+                        <DialogContent>
+                            {vlinkConflict && (
+                                <Body1>
+                                    {vlinkConflict.isVlink
+                                        ? `检测到应用外启动的 vlink（${vlinkConflict.name || 'vlink'}，PID ${vlinkConflict.pid}），可以直接接管，或停止它后由应用重新启动。`
+                                        : `端口 ${vlinkConflict.address} 已被${
+                                              vlinkConflict.pid
+                                                  ? ` ${vlinkConflict.name || '未知程序'}（PID ${vlinkConflict.pid}）`
+                                                  : '未知程序'
+                                          }占用，vlink 无法启动。`}
+                                </Body1>
+                            )}
+                            {vlinkConflictError && <Caption1>{vlinkConflictError}</Caption1>}
+                        </DialogContent>
+                        <DialogActions>
+                            <Button appearance="secondary" onClick={() => setVlinkConflict(null)}>
+                                取消
+                            </Button>
+                            {vlinkConflict?.pid ? (
+                                <Button appearance="secondary" onClick={handleReplaceExternalVlink}>
+                                    停止并重新启动
+                                </Button>
+                            ) : null}
+                            {vlinkConflict?.isVlink && (
+                                <Button appearance="primary" onClick={handleAdoptVlink}>
+                                    接管
+                                </Button>
+                            )}
+                        </DialogActions>
+                    </DialogBody>
+                </DialogSurface>
+            </Dialog>
+
+            <Dialog open={proxyDiagnoseOpen} onOpenChange={(_, data) => setProxyDiagnoseOpen(data.open)}>
+                <DialogSurface>
+                    <DialogBody>
+                        <DialogTitle>代理诊断</DialogTitle>
+                        <DialogContent>
+                            {proxyDiagnosing && <Caption1>正在诊断…</Caption1>}
+                            {proxyDiagnoseError && <Caption1>{proxyDiagnoseError}</Caption1>}
+                            {!proxyDiagnosing && proxyDiagnosis && (
+                                <>
+                                    <Caption1>
+                                        {proxyDiagnosis.proxy}：{proxyDiagnosis.ok ? '全部通过' : '存在问题'}
+                                    </Caption1>
+                                    <ul className="proxy-diagnosis">
+                                        {proxyDiagnosis.stages.map((stage) => (
+                                            <li
+                                                key={stage.name}
+                                                className={stage.skipped ? 'is-skipped' : stage.ok ? 'is-ok' : 'is-error'}
+                                            >
+                                                <span className="proxy-diagnosis-name">{stage.name}</span>
+                                                <span>{stage.skipped ? '跳过' : stage.ok ? `${stage.latencyMs} ms` : '失败'}</span>
+                                                <Caption1>{stage.detail}</Caption1>
+                                            </li>
+                                        ))}
+                                    </ul>
+                                </>
+                            )}
+                        </DialogContent>
+                        <DialogActions>
+                            <Button onClick={runProxyDiagnosis} disabled={proxyDiagnosing}>重新诊断</Button>
+                            <Button appearance="primary" onClick={() => setProxyDiagnoseOpen(false)}>关闭</Button>
+                        </DialogActions>
+                    </DialogBody>
+                </DialogSurface>
+            </Dialog>
+
             <Dialog open={updateOpen} onOpenChange={(_, data) => setUpdateOpen(data.open)}>
                 <DialogSurface>
                     <DialogBody>
@@ -778,12 +1587,48 @@ export default function App() {
                         <DialogContent>
                             {updateResult ? (
                                 <Body1>{updateResult}</Body1>
+                            ) : updateCheckError ? (
+                                <Body1>{updateCheckError}</Body1>
+                            ) : !updateStatus ? (
+                                <Spinner size="tiny" label="正在检查更新..." />
+                            ) : updateStatus.available ? (
+                                <Body1>
+                                    当前版本 {updateStatus.currentVersion}，可更新到 {updateStatus.latestVersion}。
+                                </Body1>
                             ) : (
-                                <Body1>是否立即检查并更新？</Body1>
+                                <Body1>当前版本 {updateStatus.currentVersion} 已是最新。</Body1>
+                            )}
+                            {!updateResult && updateStatus?.available && updateStatus.releaseNotes.length > 0 && (
+                                <div className="release-notes">
+                                    {updateStatus.releaseNotes.map((note) => (
+                                        <div key={note.version}>
+                                            <Subtitle2>
+                                                {note.version}
+                                                {note.date ? ` · ${note.date}` : ''}
+                                            </Subtitle2>
+                                            <pre>{note.notes}</pre>
+                                        </div>
+                                    ))}
+                                </div>
+                            )}
+                            {!updateResult && !updateInProgress && installedVersions.length > 0 && (
+                                <div className="version-history">
+                                    <Caption1>可回滚的历史版本</Caption1>
+                                    {installedVersions.map((item) => (
+                                        <div key={item.version} className="version-history-row">
+                                            <Body1>{item.version}</Body1>
+                                            <Caption1>{new Date(item.savedAt).toLocaleString()}</Caption1>
+                                            <Button size="small" appearance="subtle" onClick={() => handleRollback(item.version)}>
+                                                回滚
+                                            </Button>
+                                        </div>
+                                    ))}
+                                </div>
                             )}
                             {updateInProgress && (
                                 <div style={{ marginTop: 12 }}>
                                     <Spinner size="tiny" />
+                                    {downloadProgress && <Body1>{downloadProgress}</Body1>}
                                 </div>
                             )}
                         </DialogContent>
@@ -792,7 +1637,13 @@ export default function App() {
                                 <Button appearance="secondary" onClick={() => setUpdateOpen(false)}>取消</Button>
                             )}
                             {!updateResult && !updateInProgress && (
-                                <Button appearance="primary" onClick={handleUpdateConfirm}>开始更新</Button>
+                                <Button
+                                    appearance="primary"
+                                    disabled={!updateStatus?.available}
+                                    onClick={handleUpdateConfirm}
+                                >
+                                    开始更新
+                                </Button>
                             )}
                             {(updateResult || updateInProgress) && (
                                 <Button appearance="primary" onClick={() => setUpdateOpen(false)}>关闭</Button>
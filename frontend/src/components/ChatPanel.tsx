@@ -1,19 +1,53 @@
-import React from 'react';
-import { Button, Textarea } from '@fluentui/react-components';
+import React, { useState } from 'react';
+import { Button, Input, Select, Textarea } from '@fluentui/react-components';
 
 export type ChatMessage = {
     role: 'assistant' | 'user';
     content: string;
     id?: string;
+    pending?: boolean;
+};
+
+export type ChatSessionOption = {
+    id: string;
+    title: string;
+    updatedAt: number;
+};
+
+export type ChatSearchHit = {
+    sessionId: string;
+    sessionTitle: string;
+    messageIndex: number;
+    role: string;
+    snippet: string;
+    createdAt: number;
+};
+
+export type ProviderOption = {
+    id: string;
+    name: string;
+    model: string;
+    default: boolean;
 };
 
 type ChatPanelProps = {
     messages: ChatMessage[];
     onNewSession: () => void;
+    sessions: ChatSessionOption[];
+    sessionId: string;
+    onSelectSession: (id: string) => void;
+    onRenameSession: (id: string, title: string) => void;
+    onDeleteSession: (id: string) => void;
+    onSearch: (query: string) => Promise<ChatSearchHit[]>;
+    providers: ProviderOption[];
+    providerId: string;
+    onProviderChange: (id: string) => void;
     inputValue: string;
     onInputChange: (value: string) => void;
     onInputKeyDown: (event: React.KeyboardEvent<HTMLTextAreaElement>) => void;
     onSend: () => void;
+    streaming: boolean;
+    onStop: () => void;
     pendingAttachments: File[];
     onAttachFiles: (files: File[]) => void;
     onRemoveAttachment: (index: number) => void;
@@ -24,16 +58,59 @@ type ChatPanelProps = {
 export default function ChatPanel({
     messages,
     onNewSession,
+    sessions,
+    sessionId,
+    onSelectSession,
+    onRenameSession,
+    onDeleteSession,
+    onSearch,
+    providers,
+    providerId,
+    onProviderChange,
     inputValue,
     onInputChange,
     onInputKeyDown,
     onSend,
+    streaming,
+    onStop,
     pendingAttachments,
     onAttachFiles,
     onRemoveAttachment,
     fileInputRef,
     chatBodyRef,
 }: ChatPanelProps) {
+    const [renameDraft, setRenameDraft] = useState<string | null>(null);
+    const [searchQuery, setSearchQuery] = useState('');
+    const [searchHits, setSearchHits] = useState<ChatSearchHit[] | null>(null);
+    const currentSession = sessions.find((session) => session.id === sessionId);
+
+    const submitRename = () => {
+        const title = (renameDraft ?? '').trim();
+        if (sessionId && title) {
+            onRenameSession(sessionId, title);
+        }
+        setRenameDraft(null);
+    };
+
+    const runSearch = async () => {
+        const query = searchQuery.trim();
+        if (!query) {
+            setSearchHits(null);
+            return;
+        }
+        try {
+            setSearchHits(await onSearch(query));
+        } catch {
+            setSearchHits([]);
+        }
+    };
+
+    const openSearchHit = (hit: ChatSearchHit) => {
+        setSearchHits(null);
+        setSearchQuery('');
+        onSelectSession(hit.sessionId);
+    };
+
     return (
         <aside className="chat">
             <div className="chat-header">
@@ -41,17 +118,116 @@ export default function ChatPanel({
                     <div className="panel-title">Copilot 对话</div>
                     <div className="muted">人机协同 · 可控执行</div>
                 </div>
-                <Button appearance="secondary" size="small" onClick={onNewSession}>
-                    新建会话
-                </Button>
+                <div className="chat-header-actions">
+                    {providers.length > 0 && (
+                        <Select
+                            size="small"
+                            value={providerId}
+                            onChange={(_, data) => onProviderChange(data.value)}
+                        >
+                            {providers.map((provider) => (
+                                <option key={provider.id} value={provider.id}>
+                                    {provider.model ? `${provider.name} · ${provider.model}` : provider.name}
+                                </option>
+                            ))}
+                        </Select>
+                    )}
+                    <Button appearance="secondary" size="small" onClick={onNewSession}>
+                        新建会话
+                    </Button>
+                </div>
             </div>
-            <div className="chat-body" id="chatBody" ref={chatBodyRef}>
-                {messages.map((msg, index) => (
-                    <div className={`chat-message ${msg.role}`} key={`${msg.role}-${index}`}>
-                        <div className="bubble">{msg.content}</div>
-                    </div>
-                ))}
+            <div className="chat-search">
+                <Input
+                    size="small"
+                    value={searchQuery}
+                    onChange={(event) => {
+                        setSearchQuery(event.target.value);
+                        if (!event.target.value.trim()) setSearchHits(null);
+                    }}
+                    onKeyDown={(event) => {
+                        if (event.key === 'Enter') runSearch();
+                        if (event.key === 'Escape') {
+                            setSearchQuery('');
+                            setSearchHits(null);
+                        }
+                    }}
+                    placeholder="搜索历史对话…"
+                />
             </div>
+            {sessions.length > 0 && (
+                <div className="chat-sessions">
+                    {renameDraft !== null ? (
+                        <Input
+                            size="small"
+                            value={renameDraft}
+                            onChange={(event) => setRenameDraft(event.target.value)}
+                            onKeyDown={(event) => {
+                                if (event.key === 'Enter') submitRename();
+                                if (event.key === 'Escape') setRenameDraft(null);
+                            }}
+                            autoFocus
+                        />
+                    ) : (
+                        <Select size="small" value={sessionId} onChange={(_, data) => onSelectSession(data.value)}>
+                            {!currentSession && <option value="">未保存的会话</option>}
+                            {sessions.map((session) => (
+                                <option key={session.id} value={session.id}>
+                                    {session.title}
+                                </option>
+                            ))}
+                        </Select>
+                    )}
+                    {renameDraft !== null ? (
+                        <Button appearance="subtle" size="small" onClick={submitRename}>
+                            保存
+                        </Button>
+                    ) : (
+                        <Button
+                            appearance="subtle"
+                            size="small"
+                            disabled={!currentSession}
+                            onClick={() => setRenameDraft(currentSession?.title ?? '')}
+                        >
+                            重命名
+                        </Button>
+                    )}
+                    <Button
+                        appearance="subtle"
+                        size="small"
+                        disabled={!currentSession}
+                        onClick={() => onDeleteSession(sessionId)}
+                    >
+                        删除
+                    </Button>
+                </div>
+            )}
+            {searchHits !== null ? (
+                <div className="chat-body chat-search-results">
+                    {searchHits.length === 0 && <div className="muted">没有找到相关对话</div>}
+                    {searchHits.map((hit) => (
+                        <button
+                            type="button"
+                            className="chat-search-hit"
+                            key={`${hit.sessionId}-${hit.messageIndex}`}
+                            onClick={() => openSearchHit(hit)}
+                        >
+                            <div className="muted">
+                                {hit.sessionTitle} · {hit.role === 'user' ? '我' : '助手'}
+                            </div>
+                            <div>{hit.snippet}</div>
+                        </button>
+                    ))}
+                </div>
+            ) : (
+                <div className="chat-body" id="chatBody" ref={chatBodyRef}>
+                    {messages.map((msg, index) => (
+                        <div className={`chat-message ${msg.role}`} key={`${msg.role}-${index}`}>
+                            <div className="bubble">{msg.content}</div>
+                        </div>
+                    ))}
+                </div>
+            )}
             <div className="chat-input">
                 <div className="chat-compose">
                     <div className="chat-compose-field">
@@ -97,6 +273,11 @@ export default function ChatPanel({
                         >
                             + 附件
                         </Button>
+                        {streaming && (
+                            <Button appearance="secondary" onClick={onStop}>
+                                停止
+                            </Button>
+                        )}
                         <Button appearance="primary" onClick={onSend}>
                             发送
                         </Button>
@@ -7,7 +7,12 @@ import {
   Caption1,
   Body1,
 } from '@fluentui/react-components';
-import ChatPanel, { ChatMessage } from '../../components/ChatPanel';
+import ChatPanel, {
+  ChatMessage,
+  ChatSearchHit,
+  ChatSessionOption,
+  ProviderOption,
+} from '../../components/ChatPanel';
 import TodoList, { TodoItem } from '../../components/TodoList';
 
 type MetricItem = {
@@ -27,10 +32,21 @@ type DashboardProps = {
   activityFeed: ActivityItem[];
   messages: ChatMessage[];
   onNewSession: () => void;
+  sessions: ChatSessionOption[];
+  sessionId: string;
+  onSelectSession: (id: string) => void;
+  onRenameSession: (id: string, title: string) => void;
+  onDeleteSession: (id: string) => void;
+  onSearch: (query: string) => Promise<ChatSearchHit[]>;
+  providers: ProviderOption[];
+  providerId: string;
+  onProviderChange: (id: string) => void;
   inputValue: string;
   onInputChange: (value: string) => void;
   onInputKeyDown: (event: React.KeyboardEvent<HTMLTextAreaElement>) => void;
   onSend: () => void;
+  streaming: boolean;
+  onStop: () => void;
   pendingAttachments: File[];
   onAttachFiles: (files: File[]) => void;
   onRemoveAttachment: (index: number) => void;
@@ -49,10 +65,21 @@ export default function Dashboard({
   activityFeed,
   messages,
   onNewSession,
+  sessions,
+  sessionId,
+  onSelectSession,
+  onRenameSession,
+  onDeleteSession,
+  onSearch,
+  providers,
+  providerId,
+  onProviderChange,
   inputValue,
   onInputChange,
   onInputKeyDown,
   onSend,
+  streaming,
+  onStop,
   pendingAttachments,
   onAttachFiles,
   onRemoveAttachment,
@@ -123,10 +150,21 @@ export default function Dashboard({
       <ChatPanel
         messages={messages}
         onNewSession={onNewSession}
+        sessions={sessions}
+        sessionId={sessionId}
+        onSelectSession={onSelectSession}
+        onRenameSession={onRenameSession}
+        onDeleteSession={onDeleteSession}
+        onSearch={onSearch}
+        providers={providers}
+        providerId={providerId}
+        onProviderChange={onProviderChange}
         inputValue={inputValue}
         onInputChange={onInputChange}
         onInputKeyDown={onInputKeyDown}
         onSend={onSend}
+        streaming={streaming}
+        onStop={onStop}
         pendingAttachments={pendingAttachments}
         onAttachFiles={onAttachFiles}
         onRemoveAttachment={onRemoveAttachment}
@@ -1,5 +1,14 @@
 import React from 'react';
-import { Button, Card, Subtitle1, Caption1, Input, Textarea, Switch } from '@fluentui/react-components';
+import { Button, Card, Subtitle1, Caption1, Input, Textarea, Switch, Select } from '@fluentui/react-components';
+
+export type ProviderConfig = {
+    id: string;
+    type: string;
+    name: string;
+    baseUrl: string;
+    apiKey: string;
+    model: string;
+};
 
 export type AppSettings = {
     displayName: string;
@@ -8,6 +17,47 @@ export type AppSettings = {
     notes: string;
     pomodoroNotifyDesktop: boolean;
     pomodoroNotifySound: boolean;
+    downloadMirror: string;
+    providers: ProviderConfig[];
+    defaultProvider: string;
+    updateChannel: string;
+    activeVlinkProfile: string;
+    proxyMode: string;
+    proxyUrl: string;
+    vlinkSocksPort: number;
+    vlinkHttpPort: number;
+    proxyProbeUrls: string[];
+    vlinkRunMode: string;
+    vlinkSubscriptionRefreshHours: number;
+};
+
+export type VlinkServiceStatus = {
+    supported: boolean;
+    installed: boolean;
+    enabled: boolean;
+    activeState: string;
+    subState: string;
+    mainPid: number;
+    restarts: number;
+    exitCode: number;
+    unitPath: string;
+    message: string;
+};
+
+export type VlinkSubscription = {
+    id: string;
+    url: string;
+    name: string;
+    servers: number;
+    skipped: number;
+    updatedAt: number;
+    lastError: string;
+};
+
+const providerTypeLabels: Record<string, string> = {
+    'gemini-cli': 'Gemini CLI',
+    openai: 'OpenAI 兼容',
+    ollama: 'Ollama',
 };
 
 type SettingsProps = {
@@ -16,9 +66,72 @@ type SettingsProps = {
     onBack: () => void;
     onSave: () => void;
     onUpdate: (updater: (prev: AppSettings) => AppSettings) => void;
+    vlinkService: VlinkServiceStatus | null;
+    onVlinkServiceEnable: (enabled: boolean) => void;
+    onVlinkServiceUninstall: () => void;
+    subscriptions: VlinkSubscription[];
+    subscriptionBusy: boolean;
+    onSubscriptionImport: (url: string) => Promise<boolean>;
+    onSubscriptionRefresh: () => void;
+    onSubscriptionRemove: (id: string) => void;
+};
+
+const vlinkServiceStateLabels: Record<string, string> = {
+    active: '运行中',
+    activating: '启动中',
+    deactivating: '停止中',
+    inactive: '未运行',
+    failed: '已失败',
 };
 
-export default function Settings({ settings, error, onBack, onSave, onUpdate }: SettingsProps) {
+export default function Settings({
+    settings,
+    error,
+    onBack,
+    onSave,
+    onUpdate,
+    vlinkService,
+    onVlinkServiceEnable,
+    onVlinkServiceUninstall,
+    subscriptions,
+    subscriptionBusy,
+    onSubscriptionImport,
+    onSubscriptionRefresh,
+    onSubscriptionRemove,
+}: SettingsProps) {
+    const [subscriptionUrl, setSubscriptionUrl] = React.useState('');
+
+    const importSubscription = async () => {
+        if (await onSubscriptionImport(subscriptionUrl.trim())) {
+            setSubscriptionUrl('');
+        }
+    };
+
+    const updateProvider = (index: number, patch: Partial<ProviderConfig>) =>
+        onUpdate((prev) => ({
+            ...prev,
+            providers: prev.providers.map((provider, i) => (i === index ? { ...provider, ...patch } : provider)),
+        }));
+
+    const addOpenAIProvider = () =>
+        onUpdate((prev) => ({
+            ...prev,
+            providers: [
+                ...prev.providers,
+                {
+                    id: `openai-${Date.now()}`,
+                    type: 'openai',
+                    name: 'OpenAI 兼容服务',
+                    baseUrl: 'https://api.openai.com/v1',
+                    apiKey: '',
+                    model: '',
+                },
+            ],
+        }));
+
+    const removeProvider = (index: number) =>
+        onUpdate((prev) => ({ ...prev, providers: prev.providers.filter((_, i) => i !== index) }));
+
     return (
         <section className="settings-page">
             <div className="settings-header">
@@ -75,6 +188,259 @@ export default function Settings({ settings, error, onBack, onSave, onUpdate }:
                             onChange={(_, data) => onUpdate((prev) => ({ ...prev, vlinkAutoStart: data.checked }))}
                             label="启动时自动开启网络加速"
                         />
+                        <div className="modal-field">
+                            <Caption1>vlink 运行方式</Caption1>
+                            <Select
+                                value={settings.vlinkRunMode || 'process'}
+                                onChange={(_, data) => onUpdate((prev) => ({ ...prev, vlinkRunMode: data.value }))}
+                            >
+                                <option value="process">随应用运行（关闭应用即停止）</option>
+                                <option value="systemd">systemd 用户服务（仅 Linux，关闭应用后继续运行）</option>
+                            </Select>
+                        </div>
+                        {settings.vlinkRunMode === 'systemd' && vlinkService && (
+                            <div className="modal-field">
+                                {vlinkService.supported ? (
+                                    <>
+                                        <Caption1>
+                                            {vlinkService.installed
+                                                ? `服务状态：${vlinkServiceStateLabels[vlinkService.activeState] ?? vlinkService.activeState}${
+                                                      vlinkService.mainPid ? `（PID ${vlinkService.mainPid}）` : ''
+                                                  }`
+                                                : '服务尚未安装，开启 vlink 时会自动安装。'}
+                                        </Caption1>
+                                        <Switch
+                                            checked={vlinkService.enabled}
+                                            onChange={(_, data) => onVlinkServiceEnable(data.checked)}
+                                            label="登录时自动启动 vlink 服务"
+                                        />
+                                        {vlinkService.installed && (
+                                            <Button appearance="secondary" onClick={onVlinkServiceUninstall}>
+                                                卸载服务
+                                            </Button>
+                                        )}
+                                    </>
+                                ) : (
+                                    <Caption1>
+                                        当前系统不支持 systemd 用户服务
+                                        {vlinkService.message ? `：${vlinkService.message}` : ''}
+                                    </Caption1>
+                                )}
+                            </div>
+                        )}
+                        <div className="modal-field">
+                            <Caption1>下载镜像地址</Caption1>
+                            <Input
+                                value={settings.downloadMirror}
+                                onChange={(event) =>
+                                    onUpdate((prev) => ({ ...prev, downloadMirror: event.target.value }))
+                                }
+                                placeholder="https://qtopie.space/downloads/"
+                            />
+                        </div>
+                        <div className="modal-field">
+                            <Caption1>更新通道</Caption1>
+                            <Select
+                                value={settings.updateChannel || 'stable'}
+                                onChange={(_, data) => onUpdate((prev) => ({ ...prev, updateChannel: data.value }))}
+                            >
+                                <option value="stable">稳定版</option>
+                                <option value="beta">测试版 (beta)</option>
+                                <option value="nightly">每日构建 (nightly)</option>
+                            </Select>
+                        </div>
+                    </div>
+                </Card>
+
+                <Card className="panel">
+                    <div className="panel-title">网络代理</div>
+                    <div className="settings-form">
+                        <div className="modal-field">
+                            <Caption1>模型调用代理</Caption1>
+                            <Select
+                                value={settings.proxyMode || 'vlink'}
+                                onChange={(_, data) => onUpdate((prev) => ({ ...prev, proxyMode: data.value }))}
+                            >
+                                <option value="vlink">通过 vlink</option>
+                                <option value="system">跟随系统环境变量</option>
+                                <option value="custom">自定义代理地址</option>
+                                <option value="none">不使用代理</option>
+                            </Select>
+                        </div>
+                        {settings.proxyMode === 'custom' && (
+                            <div className="modal-field">
+                                <Caption1>代理地址</Caption1>
+                                <Input
+                                    value={settings.proxyUrl}
+                                    onChange={(event) => onUpdate((prev) => ({ ...prev, proxyUrl: event.target.value }))}
+                                    placeholder="http://127.0.0.1:7890 或 socks5://127.0.0.1:1080"
+                                />
+                            </div>
+                        )}
+                        <div className="modal-field">
+                            <Caption1>vlink SOCKS 端口（留空则读取 vlink 配置）</Caption1>
+                            <Input
+                                type="number"
+                                value={settings.vlinkSocksPort ? String(settings.vlinkSocksPort) : ''}
+                                onChange={(event) =>
+                                    onUpdate((prev) => ({ ...prev, vlinkSocksPort: Number(event.target.value) || 0 }))
+                                }
+                                placeholder="1080"
+                            />
+                        </div>
+                        <div className="modal-field">
+                            <Caption1>vlink HTTP 端口（留空则读取 vlink 配置）</Caption1>
+                            <Input
+                                type="number"
+                                value={settings.vlinkHttpPort ? String(settings.vlinkHttpPort) : ''}
+                                onChange={(event) =>
+                                    onUpdate((prev) => ({ ...prev, vlinkHttpPort: Number(event.target.value) || 0 }))
+                                }
+                                placeholder="8118"
+                            />
+                        </div>
+                        <div className="modal-field">
+                            <Caption1>代理诊断探测地址（每行一个，留空使用默认）</Caption1>
+                            <Textarea
+                                value={(settings.proxyProbeUrls ?? []).join('\n')}
+                                onChange={(event) =>
+                                    onUpdate((prev) => ({
+                                        ...prev,
+                                        proxyProbeUrls: event.target.value
+                                            .split('\n')
+                                            .map((line) => line.trim())
+                                            .filter(Boolean),
+                                    }))
+                                }
+                                placeholder="https://www.gstatic.com/generate_204"
+                                resize="vertical"
+                            />
+                        </div>
+                    </div>
+                </Card>
+
+                <Card className="panel">
+                    <div className="panel-title">vlink 订阅</div>
+                    <div className="settings-form">
+                        <div className="modal-field">
+                            <Caption1>订阅地址（支持 vmess/vless/trojan/ss 分享链接列表与 Clash 配置）</Caption1>
+                            <div className="subscription-import">
+                                <Input
+                                    value={subscriptionUrl}
+                                    onChange={(event) => setSubscriptionUrl(event.target.value)}
+                                    placeholder="https://example.com/subscribe?token=..."
+                                />
+                                <Button
+                                    appearance="primary"
+                                    disabled={subscriptionBusy || !subscriptionUrl.trim()}
+                                    onClick={importSubscription}
+                                >
+                                    导入
+                                </Button>
+                            </div>
+                        </div>
+                        {subscriptions.length > 0 && (
+                            <div className="subscription-list">
+                                {subscriptions.map((subscription) => (
+                                    <div key={subscription.id} className="subscription-item">
+                                        <div className="subscription-info">
+                                            <div className="subscription-name">{subscription.name}</div>
+                                            <Caption1>
+                                                {subscription.servers} 个节点
+                                                {subscription.skipped ? `，跳过 ${subscription.skipped} 个不支持的节点` : ''}
+                                                {subscription.updatedAt
+                                                    ? ` · 更新于 ${new Date(subscription.updatedAt).toLocaleString()}`
+                                                    : ''}
+                                            </Caption1>
+                                            {subscription.lastError && (
+                                                <Caption1 className="subscription-error">
+                                                    更新失败：{subscription.lastError}
+                                                </Caption1>
+                                            )}
+                                        </div>
+                                        <Button
+                                            appearance="secondary"
+                                            disabled={subscriptionBusy}
+                                            onClick={() => onSubscriptionRemove(subscription.id)}
+                                        >
+                                            删除
+                                        </Button>
+                                    </div>
+                                ))}
+                                <Button appearance="secondary" disabled={subscriptionBusy} onClick={onSubscriptionRefresh}>
+                                    {subscriptionBusy ? '更新中...' : '立即更新全部'}
+                                </Button>
+                            </div>
+                        )}
+                        <div className="modal-field">
+                            <Caption1>自动更新间隔（小时，0 为不自动更新）</Caption1>
+                            <Input
+                                type="number"
+                                value={String(settings.vlinkSubscriptionRefreshHours ?? 0)}
+                                onChange={(event) =>
+                                    onUpdate((prev) => ({
+                                        ...prev,
+                                        vlinkSubscriptionRefreshHours: Math.max(0, Number(event.target.value) || 0),
+                                    }))
+                                }
+                                placeholder="24"
+                            />
+                        </div>
+                        <Caption1>订阅节点写入当前 vlink 配置，重新开启 vlink 后生效。</Caption1>
+                    </div>
+                </Card>
+
+                <Card className="panel">
+                    <div className="panel-title">模型服务</div>
+                    <div className="settings-form">
+                        <div className="modal-field">
+                            <Caption1>默认模型服务</Caption1>
+                            <Select
+                                value={settings.defaultProvider}
+                                onChange={(_, data) => onUpdate((prev) => ({ ...prev, defaultProvider: data.value }))}
+                            >
+                                {settings.providers.map((provider) => (
+                                    <option key={provider.id} value={provider.id}>
+                                        {provider.name}
+                                    </option>
+                                ))}
+                            </Select>
+                        </div>
+                        {settings.providers.map((provider, index) => (
+                            <div className="modal-field" key={provider.id}>
+                                <Caption1>
+                                    {provider.name} · {providerTypeLabels[provider.type] ?? provider.type}
+                                </Caption1>
+                                {provider.type !== 'gemini-cli' && (
+                                    <Input
+                                        value={provider.baseUrl}
+                                        onChange={(event) => updateProvider(index, { baseUrl: event.target.value })}
+                                        placeholder="服务地址"
+                                    />
+                                )}
+                                {provider.type === 'openai' && (
+                                    <Input
+                                        type="password"
+                                        value={provider.apiKey}
+                                        onChange={(event) => updateProvider(index, { apiKey: event.target.value })}
+                                        placeholder="API Key"
+                                    />
+                                )}
+                                <Input
+                                    value={provider.model}
+                                    onChange={(event) => updateProvider(index, { model: event.target.value })}
+                                    placeholder="模型名称"
+                                />
+                                {provider.type === 'openai' && (
+                                    <Button appearance="subtle" size="small" onClick={() => removeProvider(index)}>
+                                        移除
+                                    </Button>
+                                )}
+                            </div>
+                        ))}
+                        <Button appearance="secondary" size="small" onClick={addOpenAIProvider}>
+                            添加 OpenAI 兼容服务
+                        </Button>
                     </div>
                 </Card>
 
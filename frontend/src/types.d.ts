@@ -13,6 +13,85 @@ type AppSettings = {
     notes: string;
     pomodoroNotifyDesktop: boolean;
     pomodoroNotifySound: boolean;
+    downloadMirror: string;
+    providers: ProviderConfig[];
+    defaultProvider: string;
+    updateChannel: string;
+    activeVlinkProfile: string;
+    proxyMode: string;
+    proxyUrl: string;
+    vlinkSocksPort: number;
+    vlinkHttpPort: number;
+    proxyProbeUrls: string[];
+    vlinkRunMode: string;
+    vlinkSubscriptionRefreshHours: number;
+};
+
+type VlinkServiceStatus = {
+    supported: boolean;
+    installed: boolean;
+    enabled: boolean;
+    activeState: string;
+    subState: string;
+    mainPid: number;
+    restarts: number;
+    exitCode: number;
+    unitPath: string;
+    message: string;
+};
+
+type VlinkSubscription = {
+    id: string;
+    url: string;
+    name: string;
+    servers: number;
+    skipped: number;
+    updatedAt: number;
+    lastError: string;
+};
+
+type ProviderConfig = {
+    id: string;
+    type: string;
+    name: string;
+    baseUrl: string;
+    apiKey: string;
+    model: string;
+};
+
+type ProviderInfo = {
+    id: string;
+    name: string;
+    type: string;
+    model: string;
+    default: boolean;
+};
+
+type ChatSessionSummary = {
+    id: string;
+    title: string;
+    providerId: string;
+    createdAt: number;
+    updatedAt: number;
+    messageCount: number;
+};
+
+type ChatSession = {
+    id: string;
+    title: string;
+    providerId: string;
+    createdAt: number;
+    updatedAt: number;
+    messages: { role: string; content: string; createdAt: number }[];
+};
+
+type ChatSearchResult = {
+    sessionId: string;
+    sessionTitle: string;
+    messageIndex: number;
+    role: string;
+    snippet: string;
+    createdAt: number;
 };
 
 type VlinkConfig = {
@@ -20,24 +99,92 @@ type VlinkConfig = {
     content: string;
 };
 
+type VlinkConfigValidation = {
+    valid: boolean;
+    saved: boolean;
+    checked: boolean;
+    diagnostics: {
+        line: number;
+        column: number;
+        severity: 'error' | 'warning';
+        source: string;
+        path: string;
+        message: string;
+    }[];
+};
+
 declare global {
     interface Window {
         go: {
             main: {
                 App: {
                     About(): Promise<string>;
+                    AdoptVlink(): Promise<string>;
+                    CancelChat(arg1: string): Promise<string>;
+                    CreateChatSession(arg1: string): Promise<ChatSessionSummary>;
+                    DeleteChatSession(arg1: string): Promise<string>;
+                    ListChatSessions(): Promise<ChatSessionSummary[]>;
+                    LoadChatSession(arg1: string): Promise<ChatSession>;
+                    RenameChatSession(arg1: string, arg2: string): Promise<string>;
+                    CheckForUpdate(): Promise<{
+                        currentVersion: string;
+                        latestVersion: string;
+                        available: boolean;
+                        releaseNotes: { version: string; date: string; notes: string }[];
+                    }>;
                     ChatWithGemini(arg1: string): Promise<string>;
                     ChatWithGeminiWithAttachments(arg1: string, arg2: GeminiAttachment[]): Promise<string>;
+                    DeleteVlinkProfile(arg1: string): Promise<string>;
+                    DiagnoseProxy(): Promise<{
+                        proxy: string;
+                        ok: boolean;
+                        stages: { name: string; ok: boolean; skipped: boolean; latencyMs: number; detail: string }[];
+                    }>;
+                    FindExternalVlink(): Promise<{ found: boolean; pid: number; name: string; address: string; source: string; isVlink: boolean }>;
+                    DiffVlinkConfig(arg1: string, arg2: string): Promise<string>;
+                    GetProxyInfo(): Promise<{
+                        mode: string;
+                        socks: { address: string; source: string };
+                        http: { address: string; source: string };
+                        url: string;
+                    }>;
                     GetSettings(): Promise<AppSettings>;
                     GetVlinkConfig(): Promise<VlinkConfig>;
-                    InstallVlink(arg1: string, arg2: string): Promise<string>;
+                    GetVlinkProfile(arg1: string): Promise<VlinkConfig>;
+                    GetVlinkLogs(
+                        arg1: number,
+                        arg2: number
+                    ): Promise<{ lines: { seq: number; time: number; stream: string; text: string }[]; firstSeq: number; nextSeq: number; path: string }>;
+                    GetVlinkServiceStatus(): Promise<VlinkServiceStatus>;
+                    GetVlinkState(): Promise<{ state: string; pid: number; exitCode: number; uptimeMs: number; restarts: number; retryInMs: number; message: string }>;
+                    ImportVlinkSubscription(arg1: string): Promise<VlinkSubscription>;
+                    InstallVlink(arg1: string): Promise<string>;
+                    InstallVlinkService(arg1: boolean): Promise<VlinkServiceStatus>;
+                    ListInstalledVersions(): Promise<{ version: string; path: string; savedAt: number; size: number }[]>;
+                    ListProviders(): Promise<ProviderInfo[]>;
+                    ListVlinkProfiles(): Promise<{ name: string; active: boolean; updatedAt: number }[]>;
+                    ListVlinkSubscriptions(): Promise<VlinkSubscription[]>;
+                    ListVlinkConfigVersions(): Promise<{ id: string; savedAt: number; comment: string; size: number }[]>;
+                    ListProviderModels(arg1: string): Promise<string[]>;
                     IsVlinkInstalled(): Promise<boolean>;
                     IsVlinkPortAlive(): Promise<boolean>;
-                    SaveVlinkConfig(arg1: string): Promise<string>;
+                    RefreshVlinkSubscriptions(): Promise<VlinkSubscription[]>;
+                    RemoveVlinkSubscription(arg1: string): Promise<string>;
+                    RestoreVlinkConfig(arg1: string): Promise<VlinkConfigValidation>;
+                    RollbackTo(arg1: string): Promise<string>;
+                    SaveVlinkProfile(arg1: string, arg2: string): Promise<VlinkConfigValidation>;
+                    SaveVlinkConfig(arg1: string, arg2: string): Promise<VlinkConfigValidation>;
                     SaveSettings(arg1: AppSettings): Promise<string>;
+                    SearchChats(arg1: string): Promise<ChatSearchResult[]>;
                     SelfUpdate(): Promise<string>;
+                    SelfUpdateFromArchive(arg1: string): Promise<string>;
                     StartVlink(): Promise<string>;
+                    StopExternalVlink(): Promise<string>;
                     StopVlink(): Promise<string>;
+                    SwitchVlinkProfile(arg1: string): Promise<string>;
+                    UninstallVlinkService(): Promise<string>;
+                    StreamChat(arg1: string, arg2: string, arg3: string, arg4: string, arg5: GeminiAttachment[]): Promise<string>;
+                    ValidateVlinkConfig(arg1: string, arg2: boolean): Promise<VlinkConfigValidation>;
                 };
             };
         };
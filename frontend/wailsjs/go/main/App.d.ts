@@ -4,25 +4,87 @@ import {main} from '../models';
 
 export function About():Promise<string>;
 
+export function AdoptVlink():Promise<string>;
+
+export function CancelChat(arg1:string):Promise<string>;
+
 export function ChatWithGemini(arg1:string):Promise<string>;
 
 export function ChatWithGeminiWithAttachments(arg1:string,arg2:Array<main.GeminiAttachment>):Promise<string>;
 
+export function CheckForUpdate():Promise<main.UpdateStatus>;
+
+export function CreateChatSession(arg1:string):Promise<main.ChatSessionSummary>;
+
+export function DeleteChatSession(arg1:string):Promise<string>;
+
+export function DeleteVlinkProfile(arg1:string):Promise<string>;
+
+export function DiagnoseProxy():Promise<main.ProxyDiagnosis>;
+
+export function DiffVlinkConfig(arg1:string,arg2:string):Promise<string>;
+
+export function FindExternalVlink():Promise<main.ExternalVlink>;
+
+export function GetProxyInfo():Promise<main.ProxyInfo>;
+
 export function GetSettings():Promise<main.AppSettings>;
 
 export function GetVlinkConfig():Promise<main.VlinkConfig>;
 
+export function GetVlinkLogs(arg1:number,arg2:number):Promise<main.VlinkLogPage>;
+
+export function GetVlinkProfile(arg1:string):Promise<main.VlinkConfig>;
+
+export function GetVlinkServiceStatus():Promise<main.VlinkServiceStatus>;
+
+export function GetVlinkState():Promise<main.VlinkState>;
+
 export function Greet(arg1:string):Promise<string>;
 
-export function InstallVlink(arg1:string,arg2:string):Promise<string>;
+export function ImportVlinkSubscription(arg1:string):Promise<main.VlinkSubscription>;
+
+export function InstallVlink(arg1:string):Promise<string>;
+
+export function InstallVlinkService(arg1:boolean):Promise<main.VlinkServiceStatus>;
 
 export function IsVlinkInstalled():Promise<boolean>;
 
 export function IsVlinkPortAlive():Promise<boolean>;
 
+export function ListChatSessions():Promise<Array<main.ChatSessionSummary>>;
+
+export function ListInstalledVersions():Promise<Array<main.InstalledVersion>>;
+
+export function ListProviderModels(arg1:string):Promise<Array<string>>;
+
+export function ListProviders():Promise<Array<main.ProviderInfo>>;
+
+export function ListVlinkConfigVersions():Promise<Array<main.VlinkConfigVersion>>;
+
+export function ListVlinkProfiles():Promise<Array<main.VlinkProfile>>;
+
+export function ListVlinkSubscriptions():Promise<Array<main.VlinkSubscription>>;
+
+export function LoadChatSession(arg1:string):Promise<main.ChatSession>;
+
+export function RefreshVlinkSubscriptions():Promise<Array<main.VlinkSubscription>>;
+
+export function RemoveVlinkSubscription(arg1:string):Promise<string>;
+
+export function RenameChatSession(arg1:string,arg2:string):Promise<string>;
+
+export function RestoreVlinkConfig(arg1:string):Promise<main.VlinkConfigValidation>;
+
+export function RollbackTo(arg1:string):Promise<string>;
+
 export function SaveSettings(arg1:main.AppSettings):Promise<string>;
 
-export function SaveVlinkConfig(arg1:string):Promise<string>;
+export function SaveVlinkConfig(arg1:string,arg2:string):Promise<main.VlinkConfigValidation>;
+
+export function SaveVlinkProfile(arg1:string,arg2:string):Promise<main.VlinkConfigValidation>;
+
+export function SearchChats(arg1:string):Promise<Array<main.ChatSearchResult>>;
 
 export function SelfUpdate():Promise<string>;
 
@@ -30,4 +92,14 @@ export function SelfUpdateFromArchive(arg1:string):Promise<string>;
 
 export function StartVlink():Promise<string>;
 
+export function StopExternalVlink():Promise<string>;
+
 export function StopVlink():Promise<string>;
+
+export function StreamChat(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<main.GeminiAttachment>):Promise<string>;
+
+export function SwitchVlinkProfile(arg1:string):Promise<string>;
+
+export function UninstallVlinkService():Promise<string>;
+
+export function ValidateVlinkConfig(arg1:string,arg2:boolean):Promise<main.VlinkConfigValidation>;
@@ -6,6 +6,14 @@ export function About() {
   return window['go']['main']['App']['About']();
 }
 
+export function AdoptVlink() {
+  return window['go']['main']['App']['AdoptVlink']();
+}
+
+export function CancelChat(arg1) {
+  return window['go']['main']['App']['CancelChat'](arg1);
+}
+
 export function ChatWithGemini(arg1) {
   return window['go']['main']['App']['ChatWithGemini'](arg1);
 }
@@ -14,6 +22,38 @@ export function ChatWithGeminiWithAttachments(arg1, arg2) {
   return window['go']['main']['App']['ChatWithGeminiWithAttachments'](arg1, arg2);
 }
 
+export function CheckForUpdate() {
+  return window['go']['main']['App']['CheckForUpdate']();
+}
+
+export function CreateChatSession(arg1) {
+  return window['go']['main']['App']['CreateChatSession'](arg1);
+}
+
+export function DeleteChatSession(arg1) {
+  return window['go']['main']['App']['DeleteChatSession'](arg1);
+}
+
+export function DeleteVlinkProfile(arg1) {
+  return window['go']['main']['App']['DeleteVlinkProfile'](arg1);
+}
+
+export function DiagnoseProxy() {
+  return window['go']['main']['App']['DiagnoseProxy']();
+}
+
+export function DiffVlinkConfig(arg1, arg2) {
+  return window['go']['main']['App']['DiffVlinkConfig'](arg1, arg2);
+}
+
+export function FindExternalVlink() {
+  return window['go']['main']['App']['FindExternalVlink']();
+}
+
+export function GetProxyInfo() {
+  return window['go']['main']['App']['GetProxyInfo']();
+}
+
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
@@ -22,12 +62,36 @@ export function GetVlinkConfig() {
   return window['go']['main']['App']['GetVlinkConfig']();
 }
 
+export function GetVlinkLogs(arg1, arg2) {
+  return window['go']['main']['App']['GetVlinkLogs'](arg1, arg2);
+}
+
+export function GetVlinkProfile(arg1) {
+  return window['go']['main']['App']['GetVlinkProfile'](arg1);
+}
+
+export function GetVlinkServiceStatus() {
+  return window['go']['main']['App']['GetVlinkServiceStatus']();
+}
+
+export function GetVlinkState() {
+  return window['go']['main']['App']['GetVlinkState']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
-export function InstallVlink(arg1, arg2) {
-  return window['go']['main']['App']['InstallVlink'](arg1, arg2);
+export function ImportVlinkSubscription(arg1) {
+  return window['go']['main']['App']['ImportVlinkSubscription'](arg1);
+}
+
+export function InstallVlink(arg1) {
+  return window['go']['main']['App']['InstallVlink'](arg1);
+}
+
+export function InstallVlinkService(arg1) {
+  return window['go']['main']['App']['InstallVlinkService'](arg1);
 }
 
 export function IsVlinkInstalled() {
@@ -38,12 +102,72 @@ export function IsVlinkPortAlive() {
   return window['go']['main']['App']['IsVlinkPortAlive']();
 }
 
+export function ListChatSessions() {
+  return window['go']['main']['App']['ListChatSessions']();
+}
+
+export function ListInstalledVersions() {
+  return window['go']['main']['App']['ListInstalledVersions']();
+}
+
+export function ListProviderModels(arg1) {
+  return window['go']['main']['App']['ListProviderModels'](arg1);
+}
+
+export function ListProviders() {
+  return window['go']['main']['App']['ListProviders']();
+}
+
+export function ListVlinkConfigVersions() {
+  return window['go']['main']['App']['ListVlinkConfigVersions']();
+}
+
+export function ListVlinkProfiles() {
+  return window['go']['main']['App']['ListVlinkProfiles']();
+}
+
+export function ListVlinkSubscriptions() {
+  return window['go']['main']['App']['ListVlinkSubscriptions']();
+}
+
+export function LoadChatSession(arg1) {
+  return window['go']['main']['App']['LoadChatSession'](arg1);
+}
+
+export function RefreshVlinkSubscriptions() {
+  return window['go']['main']['App']['RefreshVlinkSubscriptions']();
+}
+
+export function RemoveVlinkSubscription(arg1) {
+  return window['go']['main']['App']['RemoveVlinkSubscription'](arg1);
+}
+
+export function RenameChatSession(arg1, arg2) {
+  return window['go']['main']['App']['RenameChatSession'](arg1, arg2);
+}
+
+export function RestoreVlinkConfig(arg1) {
+  return window['go']['main']['App']['RestoreVlinkConfig'](arg1);
+}
+
+export function RollbackTo(arg1) {
+  return window['go']['main']['App']['RollbackTo'](arg1);
+}
+
 export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
 
-export function SaveVlinkConfig(arg1) {
-  return window['go']['main']['App']['SaveVlinkConfig'](arg1);
+export function SaveVlinkConfig(arg1, arg2) {
+  return window['go']['main']['App']['SaveVlinkConfig'](arg1, arg2);
+}
+
+export function SaveVlinkProfile(arg1, arg2) {
+  return window['go']['main']['App']['SaveVlinkProfile'](arg1, arg2);
+}
+
+export function SearchChats(arg1) {
+  return window['go']['main']['App']['SearchChats'](arg1);
 }
 
 export function SelfUpdate() {
@@ -58,6 +182,26 @@ export function StartVlink() {
   return window['go']['main']['App']['StartVlink']();
 }
 
+export function StopExternalVlink() {
+  return window['go']['main']['App']['StopExternalVlink']();
+}
+
 export function StopVlink() {
   return window['go']['main']['App']['StopVlink']();
 }
+
+export function StreamChat(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['StreamChat'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function SwitchVlinkProfile(arg1) {
+  return window['go']['main']['App']['SwitchVlinkProfile'](arg1);
+}
+
+export function UninstallVlinkService() {
+  return window['go']['main']['App']['UninstallVlinkService']();
+}
+
+export function ValidateVlinkConfig(arg1, arg2) {
+  return window['go']['main']['App']['ValidateVlinkConfig'](arg1, arg2);
+}
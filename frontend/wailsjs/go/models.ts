@@ -7,6 +7,18 @@ export namespace main {
 	    notes: string;
 	    pomodoroNotifyDesktop: boolean;
 	    pomodoroNotifySound: boolean;
+	    downloadMirror: string;
+	    providers: ProviderConfig[];
+	    defaultProvider: string;
+	    updateChannel: string;
+	    activeVlinkProfile: string;
+	    proxyMode: string;
+	    proxyUrl: string;
+	    vlinkSocksPort: number;
+	    vlinkHttpPort: number;
+	    proxyProbeUrls: string[];
+	    vlinkRunMode: string;
+	    vlinkSubscriptionRefreshHours: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -20,6 +32,160 @@ export namespace main {
 	        this.notes = source["notes"];
 	        this.pomodoroNotifyDesktop = source["pomodoroNotifyDesktop"];
 	        this.pomodoroNotifySound = source["pomodoroNotifySound"];
+	        this.downloadMirror = source["downloadMirror"];
+	        this.providers = this.convertValues(source["providers"], ProviderConfig);
+	        this.defaultProvider = source["defaultProvider"];
+	        this.updateChannel = source["updateChannel"];
+	        this.activeVlinkProfile = source["activeVlinkProfile"];
+	        this.proxyMode = source["proxyMode"];
+	        this.proxyUrl = source["proxyUrl"];
+	        this.vlinkSocksPort = source["vlinkSocksPort"];
+	        this.vlinkHttpPort = source["vlinkHttpPort"];
+	        this.proxyProbeUrls = source["proxyProbeUrls"];
+	        this.vlinkRunMode = source["vlinkRunMode"];
+	        this.vlinkSubscriptionRefreshHours = source["vlinkSubscriptionRefreshHours"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChatSearchResult {
+	    sessionId: string;
+	    sessionTitle: string;
+	    messageIndex: number;
+	    role: string;
+	    snippet: string;
+	    createdAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatSearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessionId = source["sessionId"];
+	        this.sessionTitle = source["sessionTitle"];
+	        this.messageIndex = source["messageIndex"];
+	        this.role = source["role"];
+	        this.snippet = source["snippet"];
+	        this.createdAt = source["createdAt"];
+	    }
+	}
+	export class ChatSession {
+	    id: string;
+	    title: string;
+	    providerId: string;
+	    createdAt: number;
+	    updatedAt: number;
+	    messages: ChatSessionMessage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.providerId = source["providerId"];
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	        this.messages = this.convertValues(source["messages"], ChatSessionMessage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChatSessionMessage {
+	    role: string;
+	    content: string;
+	    partial: boolean;
+	    createdAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatSessionMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.role = source["role"];
+	        this.content = source["content"];
+	        this.partial = source["partial"];
+	        this.createdAt = source["createdAt"];
+	    }
+	}
+	export class ChatSessionSummary {
+	    id: string;
+	    title: string;
+	    providerId: string;
+	    createdAt: number;
+	    updatedAt: number;
+	    messageCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatSessionSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.providerId = source["providerId"];
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	        this.messageCount = source["messageCount"];
+	    }
+	}
+	export class ExternalVlink {
+	    found: boolean;
+	    pid: number;
+	    name: string;
+	    address: string;
+	    source: string;
+	    isVlink: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExternalVlink(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.found = source["found"];
+	        this.pid = source["pid"];
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.source = source["source"];
+	        this.isVlink = source["isVlink"];
 	    }
 	}
 	export class GeminiAttachment {
@@ -38,6 +204,258 @@ export namespace main {
 	        this.isBinary = source["isBinary"];
 	    }
 	}
+	export class InstalledVersion {
+	    version: string;
+	    path: string;
+	    savedAt: number;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstalledVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.path = source["path"];
+	        this.savedAt = source["savedAt"];
+	        this.size = source["size"];
+	    }
+	}
+	export class ProviderCapabilities {
+	    streaming: boolean;
+	    listModel: boolean;
+	    offline: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderCapabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.streaming = source["streaming"];
+	        this.listModel = source["listModel"];
+	        this.offline = source["offline"];
+	    }
+	}
+	export class ProviderConfig {
+	    id: string;
+	    type: string;
+	    name: string;
+	    baseUrl: string;
+	    apiKey: string;
+	    model: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.name = source["name"];
+	        this.baseUrl = source["baseUrl"];
+	        this.apiKey = source["apiKey"];
+	        this.model = source["model"];
+	    }
+	}
+	export class ProviderInfo {
+	    id: string;
+	    name: string;
+	    type: string;
+	    model: string;
+	    default: boolean;
+	    capabilities: ProviderCapabilities;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.model = source["model"];
+	        this.default = source["default"];
+	        this.capabilities = this.convertValues(source["capabilities"], ProviderCapabilities);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProxyDiagnosis {
+	    proxy: string;
+	    ok: boolean;
+	    stages: ProxyStage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyDiagnosis(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxy = source["proxy"];
+	        this.ok = source["ok"];
+	        this.stages = this.convertValues(source["stages"], ProxyStage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProxyEndpoint {
+	    address: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyEndpoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.source = source["source"];
+	    }
+	}
+	export class ProxyInfo {
+	    mode: string;
+	    socks: ProxyEndpoint;
+	    http: ProxyEndpoint;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.socks = this.convertValues(source["socks"], ProxyEndpoint);
+	        this.http = this.convertValues(source["http"], ProxyEndpoint);
+	        this.url = source["url"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProxyStage {
+	    name: string;
+	    ok: boolean;
+	    skipped: boolean;
+	    latencyMs: number;
+	    detail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyStage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.ok = source["ok"];
+	        this.skipped = source["skipped"];
+	        this.latencyMs = source["latencyMs"];
+	        this.detail = source["detail"];
+	    }
+	}
+	export class ReleaseNote {
+	    version: string;
+	    date: string;
+	    notes: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReleaseNote(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.date = source["date"];
+	        this.notes = source["notes"];
+	    }
+	}
+	export class UpdateStatus {
+	    currentVersion: string;
+	    latestVersion: string;
+	    available: boolean;
+	    releaseNotes: ReleaseNote[];
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currentVersion = source["currentVersion"];
+	        this.latestVersion = source["latestVersion"];
+	        this.available = source["available"];
+	        this.releaseNotes = this.convertValues(source["releaseNotes"], ReleaseNote);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class VlinkConfig {
 	    path: string;
 	    content: string;
@@ -52,6 +470,230 @@ export namespace main {
 	        this.content = source["content"];
 	    }
 	}
+	export class VlinkConfigDiagnostic {
+	    line: number;
+	    column: number;
+	    severity: string;
+	    source: string;
+	    path: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkConfigDiagnostic(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.severity = source["severity"];
+	        this.source = source["source"];
+	        this.path = source["path"];
+	        this.message = source["message"];
+	    }
+	}
+	export class VlinkConfigValidation {
+	    valid: boolean;
+	    saved: boolean;
+	    checked: boolean;
+	    diagnostics: VlinkConfigDiagnostic[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkConfigValidation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valid = source["valid"];
+	        this.saved = source["saved"];
+	        this.checked = source["checked"];
+	        this.diagnostics = this.convertValues(source["diagnostics"], VlinkConfigDiagnostic);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class VlinkConfigVersion {
+	    id: string;
+	    savedAt: number;
+	    comment: string;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkConfigVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.savedAt = source["savedAt"];
+	        this.comment = source["comment"];
+	        this.size = source["size"];
+	    }
+	}
+	export class VlinkLogLine {
+	    seq: number;
+	    time: number;
+	    stream: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkLogLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = source["time"];
+	        this.stream = source["stream"];
+	        this.text = source["text"];
+	    }
+	}
+	export class VlinkLogPage {
+	    lines: VlinkLogLine[];
+	    firstSeq: number;
+	    nextSeq: number;
+	    path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkLogPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lines = this.convertValues(source["lines"], VlinkLogLine);
+	        this.firstSeq = source["firstSeq"];
+	        this.nextSeq = source["nextSeq"];
+	        this.path = source["path"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class VlinkProfile {
+	    name: string;
+	    active: boolean;
+	    updatedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.active = source["active"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class VlinkServiceStatus {
+	    supported: boolean;
+	    installed: boolean;
+	    enabled: boolean;
+	    activeState: string;
+	    subState: string;
+	    mainPid: number;
+	    restarts: number;
+	    exitCode: number;
+	    unitPath: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkServiceStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.supported = source["supported"];
+	        this.installed = source["installed"];
+	        this.enabled = source["enabled"];
+	        this.activeState = source["activeState"];
+	        this.subState = source["subState"];
+	        this.mainPid = source["mainPid"];
+	        this.restarts = source["restarts"];
+	        this.exitCode = source["exitCode"];
+	        this.unitPath = source["unitPath"];
+	        this.message = source["message"];
+	    }
+	}
+	export class VlinkState {
+	    state: string;
+	    pid: number;
+	    exitCode: number;
+	    uptimeMs: number;
+	    restarts: number;
+	    retryInMs: number;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.pid = source["pid"];
+	        this.exitCode = source["exitCode"];
+	        this.uptimeMs = source["uptimeMs"];
+	        this.restarts = source["restarts"];
+	        this.retryInMs = source["retryInMs"];
+	        this.message = source["message"];
+	    }
+	}
+	export class VlinkSubscription {
+	    id: string;
+	    url: string;
+	    name: string;
+	    servers: number;
+	    skipped: number;
+	    updatedAt: number;
+	    lastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VlinkSubscription(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.url = source["url"];
+	        this.name = source["name"];
+	        this.servers = source["servers"];
+	        this.skipped = source["skipped"];
+	        this.updatedAt = source["updatedAt"];
+	        this.lastError = source["lastError"];
+	    }
+	}
 
 }
 
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// shutdownTaskTimeout bounds how long shutdown waits for cancelled chats and
+// downloads to unwind.
+const shutdownTaskTimeout = 5 * time.Second
+
+// beginTask registers work that shutdown cancels and waits for. The returned
+// context ends when the app shuts down; done must be called when the work
+// finishes. Once shutdown has begun the context is already cancelled.
+func (a *App) beginTask() (context.Context, func()) {
+	a.tasksMu.Lock()
+	defer a.tasksMu.Unlock()
+	if a.closing {
+		return a.lifetime, func() {}
+	}
+	a.tasks.Add(1)
+	return a.lifetime, a.tasks.Done
+}
+
+// shutdown runs when the app exits. It stops a vlink child process (a
+// systemd service is meant to outlive the app and is left running, as is an
+// adopted vlink, which the next instance can adopt again from its PID file),
+// cancels chats and downloads and waits up to shutdownTaskTimeout for them,
+// then flushes the settings and the vlink log. A clean exit also clears the
+// startup sentinel, so quickly closed launches are not counted as crashes.
+func (a *App) shutdown(ctx context.Context) {
+	a.tasksMu.Lock()
+	a.closing = true
+	a.tasksMu.Unlock()
+	a.endLifetime()
+	removeStartupSentinel()
+
+	if _, err := a.stopVlinkProcess(); err != nil {
+		a.vlinkLog.logf("failed to stop vlink on exit: %v", err)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		a.tasks.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(shutdownTaskTimeout):
+		a.vlinkLog.logf("gave up waiting for chats and downloads after %s", shutdownTaskTimeout)
+	}
+
+	if err := saveSettingsToDisk(a.GetSettings()); err != nil {
+		a.vlinkLog.logf("failed to save settings on exit: %v", err)
+	}
+	_ = a.vlinkLog.Close()
+}
@@ -14,6 +14,9 @@ import (
 var assets embed.FS
 
 func main() {
+	if checkStartupCrashes() {
+		return
+	}
 	app := NewApp()
 
 	// 菜单栏
@@ -25,6 +28,12 @@ func main() {
 	FileMenu.AddText("Settings...", nil, func(_ *menu.CallbackData) {
 		wailsruntime.EventsEmit(app.ctx, "menu:settings", nil)
 	})
+	FileMenu.AddText("vlink Logs...", nil, func(_ *menu.CallbackData) {
+		wailsruntime.EventsEmit(app.ctx, "menu:vlink-logs", nil)
+	})
+	FileMenu.AddText("Diagnose Proxy...", nil, func(_ *menu.CallbackData) {
+		wailsruntime.EventsEmit(app.ctx, "menu:diagnose-proxy", nil)
+	})
 	FileMenu.AddSeparator()
 	FileMenu.AddText("Check for Updates...", nil, func(_ *menu.CallbackData) {
 		wailsruntime.EventsEmit(app.ctx, "menu:update", nil)
@@ -39,6 +48,8 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnDomReady:       app.domReady,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
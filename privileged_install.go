@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// privilegedInstallTimeout bounds how long an authentication prompt may stay
+// open before the next install method is tried.
+const privilegedInstallTimeout = 5 * time.Minute
+
+const systemVlinkBinaryPath = "/usr/local/bin/vlink"
+
+// Privileged install methods, in the order installVlinkBinary tries them.
+const (
+	installMethodPkexec = "pkexec"
+	installMethodSudo   = "sudo"
+)
+
+// errInstallCancelled is returned when the user dismisses or fails the
+// authentication prompt; the install then stops instead of trying the next
+// method.
+var errInstallCancelled = errors.New("vlink install cancelled: administrator authorization was not granted")
+
+// askpassPrompt is shown by the askpass helpers sudo -A runs.
+const askpassPrompt = "Domour Copilot 需要管理员权限以安装 vlink"
+
+// installVlinkBinary installs data as the vlink binary. It tries pkexec,
+// then sudo -A with an askpass helper, and finally falls back to
+// ~/.local/bin, which needs no privileges and which vlinkBinaryPath then
+// prefers. A refused authentication prompt cancels the install rather than
+// falling through. It returns the installed path.
+func (a *App) installVlinkBinary(data []byte) (string, error) {
+	tmpFile, err := os.CreateTemp("", "vlink-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	var failures []string
+	for _, method := range []string{installMethodPkexec, installMethodSudo} {
+		a.emitVlinkInstallStatus(fmt.Sprintf("正在写入 %s（%s 授权）", systemVlinkBinaryPath, method))
+		err := privilegedInstall(method, tmpFile.Name(), systemVlinkBinaryPath)
+		if err == nil {
+			// Drop an earlier fallback copy so it no longer shadows the
+			// system binary.
+			if userPath, err := userLocalVlinkBinaryPath(); err == nil {
+				_ = os.Remove(userPath)
+			}
+			return systemVlinkBinaryPath, nil
+		}
+		if errors.Is(err, errInstallCancelled) {
+			return "", err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", method, err))
+	}
+
+	path, err := userLocalVlinkBinaryPath()
+	if err != nil {
+		return "", err
+	}
+	a.emitVlinkInstallStatus("无法获取管理员权限，安装到 " + path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0o755); err != nil {
+		return "", fmt.Errorf("install failed (%s): %w", strings.Join(failures, "; "), err)
+	}
+	if !dirOnPath(filepath.Dir(path)) {
+		a.emitVlinkInstallStatus(filepath.Dir(path) + " 不在 PATH 中，终端里需要使用完整路径运行 vlink")
+	}
+	if fileExists(systemVlinkBinaryPath) {
+		a.emitVlinkInstallStatus(systemVlinkBinaryPath + " 未能更新，应用将改用 " + path)
+	}
+	return path, nil
+}
+
+// privilegedInstall copies src to dst as root using method.
+func privilegedInstall(method string, src string, dst string) error {
+	installPath, err := exec.LookPath("install")
+	if err != nil {
+		return err
+	}
+	args := []string{installPath, "-m", "0755", src, dst}
+
+	ctx, cancel := context.WithTimeout(context.Background(), privilegedInstallTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch method {
+	case installMethodPkexec:
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("not available on %s", runtime.GOOS)
+		}
+		pkexecPath, err := exec.LookPath("pkexec")
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, pkexecPath, args...)
+	case installMethodSudo:
+		askpass, cleanup, err := findAskpass()
+		if err != nil {
+			// Without a helper sudo can still succeed on cached
+			// credentials or a NOPASSWD rule, as long as it never prompts.
+			cmd = exec.CommandContext(ctx, "sudo", append([]string{"-n"}, args...)...)
+			break
+		}
+		defer cleanup()
+		cmd = exec.CommandContext(ctx, "sudo", append([]string{"-A"}, args...)...)
+		cmd.Env = append(os.Environ(), "SUDO_ASKPASS="+askpass)
+	default:
+		return fmt.Errorf("unknown install method %q", method)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("no authorization within %s", privilegedInstallTimeout)
+	}
+	text := strings.TrimSpace(string(output))
+	var exitErr *exec.ExitError
+	// pkexec exits 126 when the dialog is dismissed and 127 when
+	// authentication failed, which both mean the user said no. 127 is also
+	// used when there is no polkit agent to ask, and then sudo may still work.
+	if method == installMethodPkexec && errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case 126:
+			return errInstallCancelled
+		case 127:
+			if strings.Contains(strings.ToLower(text), "no authentication agent") {
+				return fmt.Errorf("no polkit agent available")
+			}
+			return errInstallCancelled
+		}
+	}
+	if text != "" {
+		return fmt.Errorf("%s", text)
+	}
+	return err
+}
+
+// findAskpass returns a program sudo -A can run to ask for the password and
+// a cleanup func for any helper script written for it. $SUDO_ASKPASS wins;
+// otherwise a graphical prompt is built from what the desktop has.
+func findAskpass() (string, func(), error) {
+	noop := func() {}
+	if path := os.Getenv("SUDO_ASKPASS"); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return path, noop, nil
+		}
+	}
+
+	var script string
+	switch runtime.GOOS {
+	case "darwin":
+		script = fmt.Sprintf("exec osascript -e 'text returned of (display dialog %q default answer \"\" with hidden answer with title \"Domour Copilot\")'\n", askpassPrompt)
+	default:
+		for _, name := range []string{"ssh-askpass", "ksshaskpass", "lxqt-openssh-askpass", "x11-ssh-askpass"} {
+			if path, err := exec.LookPath(name); err == nil {
+				return path, noop, nil
+			}
+		}
+		if _, err := exec.LookPath("zenity"); err == nil {
+			script = fmt.Sprintf("exec zenity --password --title=%q\n", askpassPrompt)
+		} else if _, err := exec.LookPath("kdialog"); err == nil {
+			script = fmt.Sprintf("exec kdialog --password %q\n", askpassPrompt)
+		} else {
+			return "", noop, fmt.Errorf("no askpass helper found")
+		}
+	}
+
+	file, err := os.CreateTemp("", "domour-askpass-*.sh")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { _ = os.Remove(file.Name()) }
+	if _, err := file.WriteString("#!/bin/sh\n" + script); err != nil {
+		_ = file.Close()
+		cleanup()
+		return "", noop, err
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	if err := os.Chmod(file.Name(), 0o700); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	return file.Name(), cleanup, nil
+}
+
+func userLocalVlinkBinaryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "bin", "vlink"), nil
+}
+
+func dirOnPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && filepath.Clean(entry) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// setProcessGroup is a no-op on Windows; killProcessGroup walks the process
+// tree with taskkill instead.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// processAlive reports whether pid names a running process.
+func processAlive(pid int) bool {
+	return pid > 0 && processName(pid) != ""
+}
+
+// signalProcess asks pid to exit, or kills it outright when force is set.
+func signalProcess(pid int, force bool) error {
+	args := []string{"/PID", strconv.Itoa(pid)}
+	if force {
+		args = append([]string{"/F"}, args...)
+	}
+	return exec.Command("taskkill", args...).Run()
+}
+
+// processName returns the executable name of pid, or "" if unknown.
+func processName(pid int) string {
+	output, err := exec.Command("tasklist", "/FO", "CSV", "/NH", "/FI", fmt.Sprintf("PID eq %d", pid)).Output()
+	if err != nil {
+		return ""
+	}
+	record, err := csv.NewReader(strings.NewReader(string(output))).Read()
+	if err != nil || len(record) < 2 || record[1] != strconv.Itoa(pid) {
+		return ""
+	}
+	return record[0]
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	providerTypeGeminiCLI = "gemini-cli"
+	providerTypeOpenAI    = "openai"
+	providerTypeOllama    = "ollama"
+)
+
+// ChatTurn is one message of a conversation sent to a provider.
+type ChatTurn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ProviderCapabilities describes what a provider supports so the UI can
+// adapt, e.g. hide the model picker when models cannot be listed.
+type ProviderCapabilities struct {
+	Streaming bool `json:"streaming"`
+	ListModel bool `json:"listModel"`
+	Offline   bool `json:"offline"`
+}
+
+// Provider is an LLM backend the chat panel can talk to.
+type Provider interface {
+	Chat(ctx context.Context, messages []ChatTurn) (string, error)
+	// Stream calls onDelta for each chunk as it arrives and returns the full
+	// response once the provider is done.
+	Stream(ctx context.Context, messages []ChatTurn, onDelta func(string)) (string, error)
+	Models(ctx context.Context) ([]string, error)
+	Capabilities() ProviderCapabilities
+}
+
+// ProviderConfig is a provider entry in AppSettings.Providers.
+type ProviderConfig struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	BaseURL string `json:"baseUrl"`
+	APIKey  string `json:"apiKey"`
+	Model   string `json:"model"`
+}
+
+// ProviderInfo is what ListProviders reports to the frontend.
+type ProviderInfo struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	Model        string               `json:"model"`
+	Default      bool                 `json:"default"`
+	Capabilities ProviderCapabilities `json:"capabilities"`
+}
+
+func defaultProviders() []ProviderConfig {
+	return []ProviderConfig{
+		defaultGeminiProvider(),
+		{ID: "ollama", Type: providerTypeOllama, Name: "Ollama", BaseURL: "http://127.0.0.1:11434", Model: "llama3.1"},
+	}
+}
+
+// defaultGeminiProvider is the built-in Gemini CLI entry, also used when the
+// settings have none.
+func defaultGeminiProvider() ProviderConfig {
+	return ProviderConfig{ID: "gemini", Type: providerTypeGeminiCLI, Name: "Gemini CLI"}
+}
+
+// newProvider builds a provider from its config. env is the environment for
+// providers that run a subprocess; nil inherits the app's.
+func newProvider(cfg ProviderConfig, env []string) (Provider, error) {
+	switch cfg.Type {
+	case providerTypeGeminiCLI:
+		return &geminiCLIProvider{cfg: cfg, env: env}, nil
+	case providerTypeOpenAI:
+		return &openAIProvider{cfg: cfg, client: &http.Client{}}, nil
+	case providerTypeOllama:
+		return &ollamaProvider{cfg: cfg, client: &http.Client{}}, nil
+	}
+	return nil, fmt.Errorf("unknown provider type %q", cfg.Type)
+}
+
+// ListProviders returns the configured chat providers.
+func (a *App) ListProviders() []ProviderInfo {
+	settings := a.GetSettings()
+	infos := make([]ProviderInfo, 0, len(settings.Providers))
+	for _, cfg := range settings.Providers {
+		provider, err := newProvider(cfg, nil)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, ProviderInfo{
+			ID:           cfg.ID,
+			Name:         cfg.Name,
+			Type:         cfg.Type,
+			Model:        cfg.Model,
+			Default:      cfg.ID == settings.DefaultProvider,
+			Capabilities: provider.Capabilities(),
+		})
+	}
+	return infos
+}
+
+// ListProviderModels asks a provider which models it can serve.
+func (a *App) ListProviderModels(providerID string) ([]string, error) {
+	provider, err := a.resolveProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return provider.Models(ctx)
+}
+
+// resolveProvider looks up providerID in the settings, falling back to the
+// default provider when it is empty.
+func (a *App) resolveProvider(providerID string) (Provider, error) {
+	settings := a.GetSettings()
+	id := strings.TrimSpace(providerID)
+	if id == "" {
+		id = settings.DefaultProvider
+	}
+	env, err := a.llmProxyEnv()
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range settings.Providers {
+		if cfg.ID == id {
+			return newProvider(cfg, env)
+		}
+	}
+	if id == "" || id == "gemini" {
+		return newProvider(defaultGeminiProvider(), env)
+	}
+	return nil, fmt.Errorf("provider %q is not configured", id)
+}
+
+// providerHTTPError turns a non-2xx response into an error carrying the
+// server's message when it sent one.
+func providerHTTPError(name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	msg := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var plain string
+		if json.Unmarshal(payload.Error, &nested) == nil && nested.Message != "" {
+			msg = nested.Message
+		} else if json.Unmarshal(payload.Error, &plain) == nil && plain != "" {
+			msg = plain
+		}
+	}
+	if msg == "" {
+		return fmt.Errorf("%s error: %s", name, resp.Status)
+	}
+	return fmt.Errorf("%s error: %s: %s", name, resp.Status, msg)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// geminiCLIProvider runs the gemini CLI in yolo mode, feeding the prompt on
+// stdin. env, when set, replaces the inherited environment so the proxy mode
+// applies.
+type geminiCLIProvider struct {
+	cfg ProviderConfig
+	env []string
+}
+
+func (p *geminiCLIProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Streaming: true}
+}
+
+func (p *geminiCLIProvider) Models(ctx context.Context) ([]string, error) {
+	return []string{"gemini-2.5-pro", "gemini-2.5-flash"}, nil
+}
+
+func (p *geminiCLIProvider) Chat(ctx context.Context, messages []ChatTurn) (string, error) {
+	cmd := p.command(ctx, flattenTurns(messages))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", geminiRunError(ctx, err, &stderr)
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	if output == "" {
+		return "", fmt.Errorf("gemini cli returned empty response")
+	}
+	return output, nil
+}
+
+// Stream reports stdout line by line; the CLI does not flush smaller chunks.
+func (p *geminiCLIProvider) Stream(ctx context.Context, messages []ChatTurn, onDelta func(string)) (string, error) {
+	cmd := p.command(ctx, flattenTurns(messages))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("gemini cli error: %v", err)
+	}
+
+	var content strings.Builder
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			content.WriteString(line)
+			onDelta(line)
+		}
+		if readErr != nil {
+			// io.EOF or a closed pipe; Wait reports how the process ended.
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return strings.TrimSpace(content.String()), geminiRunError(ctx, err, &stderr)
+	}
+
+	output := strings.TrimSpace(content.String())
+	if output == "" {
+		return "", fmt.Errorf("gemini cli returned empty response")
+	}
+	return output, nil
+}
+
+func (p *geminiCLIProvider) command(ctx context.Context, input string) *exec.Cmd {
+	args := []string{"chat", "--yolo"}
+	if p.cfg.Model != "" {
+		args = append(args, "--model", p.cfg.Model)
+	}
+	cmd := exec.CommandContext(ctx, "gemini", args...)
+	cmd.Stdin = strings.NewReader(input)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = 2 * time.Second
+	cmd.Env = p.env
+	return cmd
+}
+
+func geminiRunError(ctx context.Context, err error, stderr *bytes.Buffer) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("gemini cli timeout")
+	}
+	if ctx.Err() == context.Canceled {
+		return errChatCancelled
+	}
+	errMsg := strings.TrimSpace(stderr.String())
+	if errMsg == "" {
+		errMsg = err.Error()
+	}
+	return fmt.Errorf("gemini cli error: %s", errMsg)
+}
+
+// flattenTurns renders a conversation as a single prompt for CLIs that only
+// take one message. A lone user turn is passed through unchanged.
+func flattenTurns(messages []ChatTurn) string {
+	if len(messages) == 1 && messages[0].Role == "user" {
+		return messages[0].Content
+	}
+	var b strings.Builder
+	for i, turn := range messages {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		switch turn.Role {
+		case "system":
+			b.WriteString("System: ")
+		case "assistant":
+			b.WriteString("Assistant: ")
+		default:
+			b.WriteString("User: ")
+		}
+		b.WriteString(turn.Content)
+	}
+	return b.String()
+}
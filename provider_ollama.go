@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ollamaProvider talks to a local Ollama server, so chat keeps working on
+// machines without internet access.
+type ollamaProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model    string     `json:"model"`
+	Messages []ChatTurn `json:"messages"`
+	Stream   bool       `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message ChatTurn `json:"message"`
+	Done    bool     `json:"done"`
+	Error   string   `json:"error"`
+}
+
+func (p *ollamaProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Streaming: true, ListModel: true, Offline: true}
+}
+
+func (p *ollamaProvider) baseURL() string {
+	base := strings.TrimSpace(p.cfg.BaseURL)
+	if base == "" {
+		base = "http://127.0.0.1:11434"
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func (p *ollamaProvider) do(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL()+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, providerHTTPError("ollama", resp)
+	}
+	return resp, nil
+}
+
+func (p *ollamaProvider) Chat(ctx context.Context, messages []ChatTurn) (string, error) {
+	resp, err := p.do(ctx, http.MethodPost, "/api/chat", ollamaChatRequest{
+		Model:    p.cfg.Model,
+		Messages: messages,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	if payload.Error != "" {
+		return "", fmt.Errorf("ollama error: %s", payload.Error)
+	}
+	output := strings.TrimSpace(payload.Message.Content)
+	if output == "" {
+		return "", fmt.Errorf("ollama returned empty response")
+	}
+	return output, nil
+}
+
+// Stream reads Ollama's newline-delimited JSON chunks.
+func (p *ollamaProvider) Stream(ctx context.Context, messages []ChatTurn, onDelta func(string)) (string, error) {
+	resp, err := p.do(ctx, http.MethodPost, "/api/chat", ollamaChatRequest{
+		Model:    p.cfg.Model,
+		Messages: messages,
+		Stream:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return content.String(), fmt.Errorf("failed to decode ollama stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return content.String(), fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("ollama stream interrupted: %w", err)
+	}
+
+	output := strings.TrimSpace(content.String())
+	if output == "" {
+		return "", fmt.Errorf("ollama returned empty response")
+	}
+	return output, nil
+}
+
+func (p *ollamaProvider) Models(ctx context.Context) ([]string, error) {
+	resp, err := p.do(ctx, http.MethodGet, "/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama models: %w", err)
+	}
+	models := make([]string, 0, len(payload.Models))
+	for _, m := range payload.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
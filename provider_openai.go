@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// openAIProvider talks to any server implementing the OpenAI chat
+// completions API (OpenAI itself, vLLM, LM Studio, OpenRouter, ...).
+type openAIProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+type openAIChatRequest struct {
+	Model    string     `json:"model"`
+	Messages []ChatTurn `json:"messages"`
+	Stream   bool       `json:"stream"`
+}
+
+func (p *openAIProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Streaming: true, ListModel: true}
+}
+
+func (p *openAIProvider) baseURL() string {
+	base := strings.TrimSpace(p.cfg.BaseURL)
+	if base == "" {
+		base = "https://api.openai.com/v1"
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func (p *openAIProvider) do(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL()+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, providerHTTPError("openai", resp)
+	}
+	return resp, nil
+}
+
+func (p *openAIProvider) Chat(ctx context.Context, messages []ChatTurn) (string, error) {
+	resp, err := p.do(ctx, http.MethodPost, "/chat/completions", openAIChatRequest{
+		Model:    p.cfg.Model,
+		Messages: messages,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Choices []struct {
+			Message ChatTurn `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode openai response: %w", err)
+	}
+	if len(payload.Choices) == 0 || strings.TrimSpace(payload.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("openai returned empty response")
+	}
+	return strings.TrimSpace(payload.Choices[0].Message.Content), nil
+}
+
+// Stream reads the server-sent events of a streamed completion.
+func (p *openAIProvider) Stream(ctx context.Context, messages []ChatTurn, onDelta func(string)) (string, error) {
+	resp, err := p.do(ctx, http.MethodPost, "/chat/completions", openAIChatRequest{
+		Model:    p.cfg.Model,
+		Messages: messages,
+		Stream:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta ChatTurn `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return content.String(), fmt.Errorf("failed to decode openai stream: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		onDelta(chunk.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("openai stream interrupted: %w", err)
+	}
+
+	output := strings.TrimSpace(content.String())
+	if output == "" {
+		return "", fmt.Errorf("openai returned empty response")
+	}
+	return output, nil
+}
+
+func (p *openAIProvider) Models(ctx context.Context) ([]string, error) {
+	resp, err := p.do(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode openai models: %w", err)
+	}
+	models := make([]string, 0, len(payload.Data))
+	for _, m := range payload.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
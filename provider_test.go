@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestOpenAIProvider(server *httptest.Server) *openAIProvider {
+	return &openAIProvider{cfg: ProviderConfig{BaseURL: server.URL + "/v1", APIKey: "sk-test", Model: "gpt-test"}, client: server.Client()}
+}
+
+func newTestOllamaProvider(server *httptest.Server) *ollamaProvider {
+	return &ollamaProvider{cfg: ProviderConfig{BaseURL: server.URL, Model: "llama-test"}, client: server.Client()}
+}
+
+// writeChunks writes each chunk and flushes it so the client sees a stream.
+func writeChunks(w http.ResponseWriter, chunks ...string) {
+	for _, chunk := range chunks {
+		fmt.Fprint(w, chunk)
+		w.(http.Flusher).Flush()
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []string
+		want    string
+		deltas  []string
+		wantErr string
+	}{
+		{
+			name: "stops at DONE",
+			body: []string{
+				"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n",
+				": keep-alive\n\n",
+				"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n",
+				"data:{\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n",
+				"data: [DONE]\n\n",
+				"data: {\"choices\":[{\"delta\":{\"content\":\" ignored\"}}]}\n\n",
+			},
+			want:   "Hello",
+			deltas: []string{"Hel", "lo"},
+		},
+		{
+			name: "ends without DONE",
+			body: []string{
+				"data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n",
+			},
+			want:   "partial",
+			deltas: []string{"partial"},
+		},
+		{
+			name:    "empty stream",
+			body:    []string{"data: [DONE]\n\n"},
+			wantErr: "openai returned empty response",
+		},
+		{
+			name:    "malformed chunk",
+			body:    []string{"data: {not json}\n\n"},
+			wantErr: "failed to decode openai stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+					t.Errorf("Authorization = %q", got)
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				writeChunks(w, tt.body...)
+			}))
+			defer server.Close()
+
+			var deltas []string
+			got, err := newTestOpenAIProvider(server).Stream(context.Background(), []ChatTurn{{Role: "user", Content: "hi"}}, func(delta string) {
+				deltas = append(deltas, delta)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if strings.Join(deltas, "|") != strings.Join(tt.deltas, "|") {
+				t.Errorf("deltas = %q, want %q", deltas, tt.deltas)
+			}
+		})
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "stops at done",
+			body: []string{
+				`{"message":{"role":"assistant","content":"Hi"},"done":false}` + "\n",
+				"\n",
+				`{"message":{"role":"assistant","content":" there"},"done":false}` + "\n",
+				`{"message":{"role":"assistant","content":""},"done":true}` + "\n",
+				`{"message":{"role":"assistant","content":" ignored"},"done":false}` + "\n",
+			},
+			want: "Hi there",
+		},
+		{
+			name: "error chunk",
+			body: []string{
+				`{"message":{"content":"Hi"},"done":false}` + "\n",
+				`{"error":"model not loaded"}` + "\n",
+			},
+			wantErr: "ollama error: model not loaded",
+		},
+		{
+			name:    "malformed chunk",
+			body:    []string{"not json\n"},
+			wantErr: "failed to decode ollama stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/chat" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				writeChunks(w, tt.body...)
+			}))
+			defer server.Close()
+
+			got, err := newTestOllamaProvider(server).Stream(context.Background(), []ChatTurn{{Role: "user", Content: "hi"}}, func(string) {})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderChatAndModels(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":" openai answer "}}]}`)
+	})
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"gpt-a"},{"id":"gpt-b"}]}`)
+	})
+	mux.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"ollama answer"},"done":true}`)
+	})
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"name":"llama3.1"},{"name":"qwen2"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	providers := []struct {
+		name     string
+		provider Provider
+		answer   string
+		models   string
+	}{
+		{"openai", newTestOpenAIProvider(server), "openai answer", "gpt-a,gpt-b"},
+		{"ollama", newTestOllamaProvider(server), "ollama answer", "llama3.1,qwen2"},
+	}
+	for _, tt := range providers {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, err := tt.provider.Chat(context.Background(), []ChatTurn{{Role: "user", Content: "hi"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if answer != tt.answer {
+				t.Errorf("answer = %q, want %q", answer, tt.answer)
+			}
+			models, err := tt.provider.Models(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(models, ","); got != tt.models {
+				t.Errorf("models = %s, want %s", got, tt.models)
+			}
+		})
+	}
+}
+
+func TestProviderHTTPError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"nested message", http.StatusUnauthorized, `{"error":{"message":"invalid api key","type":"auth"}}`, "401 Unauthorized: invalid api key"},
+		{"plain error", http.StatusNotFound, `{"error":"model \"x\" not found"}`, `404 Not Found: model "x" not found`},
+		{"text body", http.StatusBadGateway, "upstream down\n", "502 Bad Gateway: upstream down"},
+		{"empty body", http.StatusInternalServerError, "", "500 Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			for name, provider := range map[string]Provider{
+				"openai": newTestOpenAIProvider(server),
+				"ollama": newTestOllamaProvider(server),
+			} {
+				_, err := provider.Stream(context.Background(), nil, func(string) {})
+				want := name + " error: " + tt.want
+				if err == nil || err.Error() != want {
+					t.Errorf("%s: err = %v, want %q", name, err, want)
+				}
+				if _, err := provider.Models(context.Background()); err == nil || err.Error() != want {
+					t.Errorf("%s models: err = %v, want %q", name, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderStreamCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		provider func(*httptest.Server) Provider
+	}{
+		{"openai", "data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n\n", func(s *httptest.Server) Provider { return newTestOpenAIProvider(s) }},
+		{"ollama", `{"message":{"content":"first"},"done":false}` + "\n", func(s *httptest.Server) Provider { return newTestOllamaProvider(s) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeChunks(w, tt.first)
+				// Hold the stream open until the client goes away.
+				<-r.Context().Done()
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			got, err := tt.provider(server).Stream(ctx, nil, func(string) { cancel() })
+			if err == nil {
+				t.Fatal("expected an error after cancel")
+			}
+			if ctx.Err() == nil {
+				t.Fatal("context was not cancelled")
+			}
+			if got != "first" {
+				t.Errorf("partial content = %q, want %q", got, "first")
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Proxy modes for LLM subprocesses.
+const (
+	// proxyModeNone strips proxy variables from the environment.
+	proxyModeNone = "none"
+	// proxyModeSystem passes the app's own environment through unchanged.
+	proxyModeSystem = "system"
+	// proxyModeVlink points the proxy variables at vlink's local inbounds.
+	proxyModeVlink = "vlink"
+	// proxyModeCustom uses AppSettings.ProxyURL.
+	proxyModeCustom = "custom"
+)
+
+// Ports assumed when neither the settings nor the vlink config name one.
+const (
+	defaultVlinkSocksPort = 1080
+	defaultVlinkHTTPPort  = 8118
+)
+
+// Where a proxy endpoint came from.
+const (
+	proxySourceSettings = "settings"
+	proxySourceConfig   = "config"
+	proxySourceDefault  = "default"
+)
+
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "http_proxy", "https_proxy", "all_proxy"}
+
+// ProxyEndpoint is a local vlink inbound.
+type ProxyEndpoint struct {
+	Address string `json:"address"`
+	Source  string `json:"source"`
+}
+
+// ProxyInfo describes the proxy the app uses for LLM subprocesses.
+type ProxyInfo struct {
+	Mode  string        `json:"mode"`
+	Socks ProxyEndpoint `json:"socks"`
+	HTTP  ProxyEndpoint `json:"http"`
+	// URL is what the proxy variables are set to; empty for none and system.
+	URL string `json:"url"`
+}
+
+// GetProxyInfo reports the proxy mode and the vlink endpoints derived from
+// the settings and the vlink config.
+func (a *App) GetProxyInfo() (ProxyInfo, error) {
+	settings := a.GetSettings()
+	socks, httpProxy := a.vlinkProxyEndpoints()
+	info := ProxyInfo{Mode: normalizeProxyMode(settings.ProxyMode), Socks: socks, HTTP: httpProxy}
+	proxyURL, err := a.proxyURL()
+	info.URL = proxyURL
+	return info, err
+}
+
+func normalizeProxyMode(mode string) string {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case proxyModeNone, proxyModeSystem, proxyModeCustom:
+		return mode
+	}
+	return proxyModeVlink
+}
+
+// vlinkProxyEndpoints returns vlink's SOCKS and HTTP inbounds. A port set in
+// the settings wins, then the inbound in the vlink config, then the
+// historical defaults.
+func (a *App) vlinkProxyEndpoints() (ProxyEndpoint, ProxyEndpoint) {
+	settings := a.GetSettings()
+	inbounds := vlinkConfigInbounds()
+	resolve := func(override int, protocol string, fallback int) ProxyEndpoint {
+		if override > 0 {
+			return ProxyEndpoint{Address: net.JoinHostPort("127.0.0.1", strconv.Itoa(override)), Source: proxySourceSettings}
+		}
+		if address, ok := inbounds[protocol]; ok {
+			return ProxyEndpoint{Address: address, Source: proxySourceConfig}
+		}
+		return ProxyEndpoint{Address: net.JoinHostPort("127.0.0.1", strconv.Itoa(fallback)), Source: proxySourceDefault}
+	}
+	return resolve(settings.VlinkSocksPort, "socks", defaultVlinkSocksPort),
+		resolve(settings.VlinkHTTPPort, "http", defaultVlinkHTTPPort)
+}
+
+// vlinkConfigInbounds reads the first SOCKS and HTTP inbound from the vlink
+// config vlink would start with, keyed "socks" and "http". A "mixed" inbound
+// serves both. Wildcard listen addresses are reached over loopback.
+func vlinkConfigInbounds() map[string]string {
+	found := make(map[string]string)
+	data, err := os.ReadFile(currentVlinkConfigPath())
+	if err != nil {
+		return found
+	}
+	var config struct {
+		Inbounds []struct {
+			Protocol string          `json:"protocol"`
+			Listen   string          `json:"listen"`
+			Port     json.RawMessage `json:"port"`
+		} `json:"inbounds"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return found
+	}
+	for _, inbound := range config.Inbounds {
+		var raw interface{}
+		decoder := json.NewDecoder(strings.NewReader(string(inbound.Port)))
+		decoder.UseNumber()
+		if decoder.Decode(&raw) != nil {
+			continue
+		}
+		port, err := jsonPort(raw)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSpace(inbound.Listen)
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		address := net.JoinHostPort(host, strconv.FormatInt(port, 10))
+		var protocols []string
+		switch strings.ToLower(inbound.Protocol) {
+		case "socks", "socks5":
+			protocols = []string{"socks"}
+		case "http":
+			protocols = []string{"http"}
+		case "mixed":
+			protocols = []string{"socks", "http"}
+		}
+		for _, protocol := range protocols {
+			if _, ok := found[protocol]; !ok {
+				found[protocol] = address
+			}
+		}
+	}
+	return found
+}
+
+// currentVlinkConfigPath mirrors resolveVlinkConfigPath without creating a
+// config when there is none.
+func currentVlinkConfigPath() string {
+	homeConfig, err := vlinkHomeConfigPath()
+	if err == nil && fileExists(homeConfig) {
+		return homeConfig
+	}
+	if runtime.GOOS != "windows" && fileExists("/etc/vlink/config.json") {
+		return "/etc/vlink/config.json"
+	}
+	return homeConfig
+}
+
+// proxyURL is the URL LLM subprocesses should use, or "" for none and
+// system modes.
+func (a *App) proxyURL() (string, error) {
+	settings := a.GetSettings()
+	switch normalizeProxyMode(settings.ProxyMode) {
+	case proxyModeVlink:
+		socks, httpProxy := a.vlinkProxyEndpoints()
+		// Prefer an HTTP inbound the config actually declares; most CLIs
+		// only understand http:// proxies.
+		if httpProxy.Source != proxySourceDefault || socks.Source == proxySourceDefault {
+			return "http://" + httpProxy.Address, nil
+		}
+		return "socks5://" + socks.Address, nil
+	case proxyModeCustom:
+		raw := strings.TrimSpace(settings.ProxyURL)
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			return "", fmt.Errorf("custom proxy URL %q is invalid", raw)
+		}
+		switch parsed.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return "", fmt.Errorf("custom proxy URL %q must use http, https or socks5", raw)
+		}
+		return raw, nil
+	}
+	return "", nil
+}
+
+// llmProxyEnv builds the environment for LLM subprocesses according to the
+// proxy mode.
+func (a *App) llmProxyEnv() ([]string, error) {
+	mode := normalizeProxyMode(a.GetSettings().ProxyMode)
+	if mode == proxyModeSystem {
+		return os.Environ(), nil
+	}
+	env := withoutProxyEnv(os.Environ())
+	if mode == proxyModeNone {
+		return env, nil
+	}
+	proxyURL, err := a.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range proxyEnvNames {
+		env = append(env, name+"="+proxyURL)
+	}
+	return env, nil
+}
+
+func withoutProxyEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		drop := false
+		for _, proxyName := range proxyEnvNames {
+			if name == proxyName {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	proxyDiagnoseStageTimeout = 5 * time.Second
+	proxyProbeTimeout         = 10 * time.Second
+)
+
+// defaultProxyProbeURLs are used when AppSettings.ProxyProbeURLs is empty.
+var defaultProxyProbeURLs = []string{
+	"https://www.gstatic.com/generate_204",
+	"https://api.github.com",
+}
+
+// ProxyStage is the outcome of one step of DiagnoseProxy. Stages after a
+// failure that depend on it are reported as skipped.
+type ProxyStage struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Skipped   bool   `json:"skipped"`
+	LatencyMs int64  `json:"latencyMs"`
+	Detail    string `json:"detail"`
+}
+
+// ProxyDiagnosis is the result of DiagnoseProxy.
+type ProxyDiagnosis struct {
+	Proxy  string       `json:"proxy"`
+	OK     bool         `json:"ok"`
+	Stages []ProxyStage `json:"stages"`
+}
+
+// DiagnoseProxy checks vlink's SOCKS inbound end to end: TCP connect, SOCKS5
+// handshake, a CONNECT by host name so the proxy has to resolve it, and an
+// HTTP request to each probe URL through the proxy.
+func (a *App) DiagnoseProxy() (ProxyDiagnosis, error) {
+	socks, _ := a.vlinkProxyEndpoints()
+	probes := a.GetSettings().ProxyProbeURLs
+	if len(probes) == 0 {
+		probes = defaultProxyProbeURLs
+	}
+	return diagnoseProxy(context.Background(), socks.Address, probes), nil
+}
+
+func diagnoseProxy(ctx context.Context, socksAddr string, probes []string) ProxyDiagnosis {
+	diagnosis := ProxyDiagnosis{Proxy: "socks5://" + socksAddr, OK: true}
+	// run records one stage; skip marks stages that cannot run because a
+	// stage they depend on failed.
+	run := func(name string, skip bool, stage func() (string, error)) bool {
+		if skip {
+			diagnosis.Stages = append(diagnosis.Stages, ProxyStage{Name: name, Skipped: true, Detail: "skipped after an earlier failure"})
+			diagnosis.OK = false
+			return false
+		}
+		start := time.Now()
+		detail, err := stage()
+		result := ProxyStage{Name: name, OK: err == nil, LatencyMs: time.Since(start).Milliseconds(), Detail: detail}
+		if err != nil {
+			result.Detail = err.Error()
+			diagnosis.OK = false
+		}
+		diagnosis.Stages = append(diagnosis.Stages, result)
+		return err == nil
+	}
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	ok := run("tcp", false, func() (string, error) {
+		var err error
+		dialer := net.Dialer{Timeout: proxyDiagnoseStageTimeout}
+		conn, err = dialer.DialContext(ctx, "tcp", socksAddr)
+		if err != nil {
+			return "", fmt.Errorf("cannot connect to %s: %w", socksAddr, err)
+		}
+		return "connected to " + socksAddr, nil
+	})
+	ok = run("socks5-handshake", !ok, func() (string, error) {
+		_ = conn.SetDeadline(time.Now().Add(proxyDiagnoseStageTimeout))
+		if err := socks5Greet(conn); err != nil {
+			return "", err
+		}
+		return "no-auth method accepted", nil
+	})
+
+	dnsHost, dnsPort := "www.gstatic.com", 443
+	if len(probes) > 0 {
+		if host, port, valid := probeHostPort(probes[0]); valid {
+			dnsHost, dnsPort = host, port
+		}
+	}
+	ok = run("dns", !ok, func() (string, error) {
+		_ = conn.SetDeadline(time.Now().Add(proxyDiagnoseStageTimeout))
+		bound, err := socks5ConnectDomain(conn, dnsHost, dnsPort)
+		if err != nil {
+			return "", fmt.Errorf("proxy could not reach %s: %w", dnsHost, err)
+		}
+		return fmt.Sprintf("%s resolved and reached through the proxy (bound %s)", dnsHost, bound), nil
+	})
+
+	client := &http.Client{
+		Timeout: proxyProbeTimeout,
+		Transport: &http.Transport{
+			Proxy:             http.ProxyURL(&url.URL{Scheme: "socks5", Host: socksAddr}),
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	// Probes only depend on the proxy working, not on each other.
+	for _, probe := range probes {
+		run("probe "+probe, !ok, func() (string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe, nil)
+			if err != nil {
+				return "", err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			if resp.StatusCode >= 500 {
+				return "", fmt.Errorf("probe returned %s", resp.Status)
+			}
+			return resp.Status, nil
+		})
+	}
+	return diagnosis
+}
+
+func probeHostPort(raw string) (string, int, bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return "", 0, false
+	}
+	port := 80
+	if parsed.Scheme == "https" {
+		port = 443
+	}
+	if p := parsed.Port(); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", 0, false
+		}
+		port = n
+	}
+	return parsed.Hostname(), port, true
+}
+
+// socks5Greet offers only the no-authentication method (RFC 1928 §3), which
+// is what vlink's local inbound accepts.
+func socks5Greet(conn net.Conn) error {
+	if _, err := conn.Write([]byte{0x05, 0x01, 0x00}); err != nil {
+		return fmt.Errorf("failed to send greeting: %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("no SOCKS5 reply, is this a SOCKS port? %w", err)
+	}
+	if reply[0] != 0x05 {
+		return fmt.Errorf("not a SOCKS5 server (version byte %#x)", reply[0])
+	}
+	if reply[1] != 0x00 {
+		return fmt.Errorf("proxy requires authentication (method %#x)", reply[1])
+	}
+	return nil
+}
+
+// socks5ConnectDomain sends CONNECT with a domain-name address so the proxy
+// does the lookup, and returns the bound address from its reply.
+func socks5ConnectDomain(conn net.Conn, host string, port int) (string, error) {
+	if len(host) > 255 {
+		return "", fmt.Errorf("host name too long")
+	}
+	req := []byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}
+	req = append(req, host...)
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return "", fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("no CONNECT reply: %w", err)
+	}
+	if header[1] != 0x00 {
+		return "", fmt.Errorf("%s", socks5ReplyText(header[1]))
+	}
+	var addr []byte
+	switch header[3] {
+	case 0x01:
+		addr = make([]byte, net.IPv4len)
+	case 0x04:
+		addr = make([]byte, net.IPv6len)
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", err
+		}
+		addr = make([]byte, size[0])
+	default:
+		return "", fmt.Errorf("unknown address type %#x in reply", header[3])
+	}
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, addr); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return "", err
+	}
+	boundHost := string(addr)
+	if header[3] != 0x03 {
+		boundHost = net.IP(addr).String()
+	}
+	return net.JoinHostPort(boundHost, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))), nil
+}
+
+func socks5ReplyText(code byte) string {
+	switch code {
+	case 0x01:
+		return "general SOCKS server failure"
+	case 0x02:
+		return "connection not allowed by ruleset"
+	case 0x03:
+		return "network unreachable"
+	case 0x04:
+		return "host unreachable (DNS lookup may have failed)"
+	case 0x05:
+		return "connection refused"
+	case 0x06:
+		return "TTL expired"
+	case 0x07:
+		return "command not supported"
+	case 0x08:
+		return "address type not supported"
+	}
+	return fmt.Sprintf("SOCKS error %#x", code)
+}
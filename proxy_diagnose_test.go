@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSocksMode selects how fakeSocksServer answers.
+type fakeSocksMode int
+
+const (
+	fakeSocksOK fakeSocksMode = iota
+	fakeSocksAuthRequired
+	fakeSocksConnectFails
+	fakeSocksNotSocks
+)
+
+// fakeSocksServer is a minimal SOCKS5 stand-in for vlink's inbound. In
+// fakeSocksOK mode it relays CONNECTs to the requested address.
+func fakeSocksServer(t *testing.T, mode fakeSocksMode) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSocks(conn, mode)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveFakeSocks(conn net.Conn, mode fakeSocksMode) {
+	defer conn.Close()
+	if mode == fakeSocksNotSocks {
+		_, _ = io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\n\r\n")
+		return
+	}
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, greeting[1])); err != nil {
+		return
+	}
+	if mode == fakeSocksAuthRequired {
+		_, _ = conn.Write([]byte{0x05, 0x02})
+		return
+	}
+	_, _ = conn.Write([]byte{0x05, 0x00})
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	var host string
+	switch header[3] {
+	case 0x01:
+		addr := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = net.IP(addr).String()
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		addr := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = string(addr)
+	default:
+		_, _ = conn.Write([]byte{0x05, 0x08, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return
+	}
+	if mode == fakeSocksConnectFails {
+		_, _ = conn.Write([]byte{0x05, 0x04, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))))
+	if err != nil {
+		_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0x04, 0x38})
+	go func() { _, _ = io.Copy(target, conn) }()
+	_, _ = io.Copy(conn, target)
+}
+
+// refusedAddr returns an address nothing listens on.
+func refusedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestDiagnoseProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	probe := target.URL + "/generate_204"
+
+	tests := []struct {
+		name string
+		addr func(t *testing.T) string
+		ok   bool
+		want map[string]string // stage -> "ok", "skipped" or a substring of the failure
+	}{
+		{
+			name: "healthy proxy",
+			addr: func(t *testing.T) string { return fakeSocksServer(t, fakeSocksOK) },
+			ok:   true,
+			want: map[string]string{"tcp": "ok", "socks5-handshake": "ok", "dns": "ok", "probe " + probe: "ok"},
+		},
+		{
+			name: "authentication required",
+			addr: func(t *testing.T) string { return fakeSocksServer(t, fakeSocksAuthRequired) },
+			want: map[string]string{"tcp": "ok", "socks5-handshake": "proxy requires authentication (method 0x2)", "dns": "skipped", "probe " + probe: "skipped"},
+		},
+		{
+			name: "connect fails",
+			addr: func(t *testing.T) string { return fakeSocksServer(t, fakeSocksConnectFails) },
+			want: map[string]string{"tcp": "ok", "socks5-handshake": "ok", "dns": "host unreachable", "probe " + probe: "skipped"},
+		},
+		{
+			name: "not a SOCKS port",
+			addr: func(t *testing.T) string { return fakeSocksServer(t, fakeSocksNotSocks) },
+			want: map[string]string{"tcp": "ok", "socks5-handshake": "not a SOCKS5 server", "dns": "skipped", "probe " + probe: "skipped"},
+		},
+		{
+			name: "connection refused",
+			addr: refusedAddr,
+			want: map[string]string{"tcp": "cannot connect to", "socks5-handshake": "skipped", "dns": "skipped", "probe " + probe: "skipped"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis := diagnoseProxy(context.Background(), tt.addr(t), []string{probe})
+			if diagnosis.OK != tt.ok {
+				t.Errorf("OK = %v, want %v", diagnosis.OK, tt.ok)
+			}
+			if len(diagnosis.Stages) != len(tt.want) {
+				t.Fatalf("got %d stages, want %d: %+v", len(diagnosis.Stages), len(tt.want), diagnosis.Stages)
+			}
+			for _, stage := range diagnosis.Stages {
+				want, found := tt.want[stage.Name]
+				if !found {
+					t.Errorf("unexpected stage %q", stage.Name)
+					continue
+				}
+				got := stageOutcome(stage)
+				matched := got == want
+				if want != "ok" && want != "skipped" {
+					matched = strings.HasPrefix(got, "failed: ") && strings.Contains(got, want)
+				}
+				if !matched {
+					t.Errorf("stage %s = %q, want %q", stage.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func stageOutcome(stage ProxyStage) string {
+	switch {
+	case stage.Skipped:
+		return "skipped"
+	case stage.OK:
+		return "ok"
+	}
+	return fmt.Sprintf("failed: %s", stage.Detail)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// releaseKey is a release signing key. Raw ed25519 keys carry no key ID;
+// minisign keys do, and signatures must reference the same ID.
+type releaseKey struct {
+	keyID []byte
+	key   ed25519.PublicKey
+}
+
+// parseReleaseKey accepts either a base64 raw ed25519 public key or a
+// minisign public key (the base64 line of minisign.pub).
+func parseReleaseKey(encoded string) (releaseKey, error) {
+	lines := nonEmptyLines(encoded)
+	if len(lines) == 0 {
+		return releaseKey{}, fmt.Errorf("release signing key is empty")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[len(lines)-1])
+	if err != nil {
+		return releaseKey{}, fmt.Errorf("release signing key is not valid base64: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.PublicKeySize:
+		return releaseKey{key: ed25519.PublicKey(raw)}, nil
+	case 2 + 8 + ed25519.PublicKeySize:
+		if string(raw[:2]) != "Ed" {
+			return releaseKey{}, fmt.Errorf("unsupported minisign key algorithm %q", raw[:2])
+		}
+		return releaseKey{keyID: raw[2:10], key: ed25519.PublicKey(raw[10:])}, nil
+	}
+	return releaseKey{}, fmt.Errorf("release signing key has unexpected length %d", len(raw))
+}
+
+// verifyChecksumsSignature checks the detached signature of checksums.txt.
+// The signature is either a base64 raw ed25519 signature or a minisign
+// signature file.
+func verifyChecksumsSignature(checksums []byte, signature string, encodedKey string) error {
+	if strings.TrimSpace(encodedKey) == "" {
+		return fmt.Errorf("this build has no release signing key, refusing to install unverified releases")
+	}
+	key, err := parseReleaseKey(encodedKey)
+	if err != nil {
+		return err
+	}
+	lines := nonEmptyLines(signature)
+	if len(lines) == 0 {
+		return fmt.Errorf("checksums.txt signature is empty")
+	}
+	if strings.HasPrefix(lines[0], "untrusted comment:") {
+		return verifyMinisign(key, checksums, lines)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("checksums.txt signature is malformed")
+	}
+	if !ed25519.Verify(key.key, checksums, sig) {
+		return fmt.Errorf("checksums.txt signature is invalid, refusing to install")
+	}
+	return nil
+}
+
+func verifyMinisign(key releaseKey, message []byte, lines []string) error {
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("minisign signature is malformed")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("minisign signature is malformed")
+	}
+	alg, keyID, sig := string(raw[:2]), raw[2:10], raw[10:]
+	if key.keyID != nil && !bytes.Equal(key.keyID, keyID) {
+		return fmt.Errorf("checksums.txt was signed with an unknown key %X", keyID)
+	}
+
+	switch alg {
+	case "Ed":
+	case "ED":
+		// Prehashed signatures (the minisign default) sign the BLAKE2b-512 digest.
+		digest := blake2b.Sum512(message)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", alg)
+	}
+	if !ed25519.Verify(key.key, message, sig) {
+		return fmt.Errorf("checksums.txt signature is invalid, refusing to install")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("minisign trusted comment signature is malformed")
+	}
+	if !ed25519.Verify(key.key, append(append([]byte{}, sig...), trusted...), globalSig) {
+		return fmt.Errorf("minisign trusted comment signature is invalid")
+	}
+	return nil
+}
+
+func nonEmptyLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	updateCheckInitialDelay = 10 * time.Second
+	updateCheckInterval     = 6 * time.Hour
+)
+
+// UpdateStatus compares the running version with the latest release.
+type UpdateStatus struct {
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	Available      bool   `json:"available"`
+	// ReleaseNotes covers the releases after CurrentVersion up to
+	// LatestVersion. Only CheckForUpdate fills it in.
+	ReleaseNotes []ReleaseNote `json:"releaseNotes"`
+}
+
+// ReleaseNote is one release's entry from release.json or the changelog.
+type ReleaseNote struct {
+	Version string `json:"version"`
+	Date    string `json:"date"`
+	Notes   string `json:"notes"`
+}
+
+// CheckForUpdate reports whether a newer release is available on the
+// configured channel, along with its release notes, without downloading it.
+// Missing release notes are not an error.
+func (a *App) CheckForUpdate() (UpdateStatus, error) {
+	status, err := a.checkLatestVersion()
+	if err != nil {
+		return status, err
+	}
+	status.ReleaseNotes = []ReleaseNote{}
+	if status.LatestVersion != "" {
+		status.ReleaseNotes = fetchReleaseNotes(a.releaseBaseURL("domour"), appVersion, status.LatestVersion)
+	}
+	return status, nil
+}
+
+// checkLatestVersion looks up the newest release on the download server.
+func (a *App) checkLatestVersion() (UpdateStatus, error) {
+	status := UpdateStatus{CurrentVersion: appVersion}
+	checksums, err := fetchChecksums(a.releaseBaseURL("domour"))
+	if err != nil {
+		return status, err
+	}
+	latest, err := latestVersionFromChecksums(checksums, "domour-copilot", a.GetSettings().UpdateChannel)
+	if err != nil {
+		return status, err
+	}
+	status.LatestVersion = latest
+	status.Available = isValidSemver(appVersion) && compareSemver(latest, appVersion) > 0
+	return status, nil
+}
+
+// runUpdateScheduler checks for updates shortly after launch and then
+// periodically while AutoUpdate is enabled, reporting through update:available
+// and update:error events. The setting is re-read before every check so
+// toggling it takes effect without a restart.
+func (a *App) runUpdateScheduler() {
+	if !isValidSemver(appVersion) {
+		// Development builds have no version to compare against.
+		return
+	}
+	timer := time.NewTimer(updateCheckInitialDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-a.lifetime.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(updateCheckInterval)
+
+		if !a.GetSettings().AutoUpdate {
+			continue
+		}
+		status, err := a.checkLatestVersion()
+		if err != nil {
+			wailsruntime.EventsEmit(a.ctx, "update:error", err.Error())
+			continue
+		}
+		if status.Available {
+			wailsruntime.EventsEmit(a.ctx, "update:available", status)
+		}
+	}
+}
+
+// fetchReleaseNotes looks for release.json, then CHANGELOG.md, next to
+// checksums.txt and returns the entries newer than current up to latest,
+// newest first. When current is not a release version only latest is
+// returned. Release notes are informational and are not covered by the
+// checksums signature.
+func fetchReleaseNotes(baseURL string, current string, latest string) []ReleaseNote {
+	var notes []ReleaseNote
+	if data, err := fetchReleaseFile(baseURL, "release.json"); err == nil {
+		notes = parseReleaseJSON(data)
+	} else if data, err := fetchReleaseFile(baseURL, "CHANGELOG.md"); err == nil {
+		notes = parseChangelog(string(data))
+	}
+
+	selected := []ReleaseNote{}
+	for _, note := range notes {
+		if !isValidSemver(note.Version) || compareSemver(note.Version, latest) > 0 {
+			continue
+		}
+		if isValidSemver(current) {
+			if compareSemver(note.Version, current) <= 0 {
+				continue
+			}
+		} else if compareSemver(note.Version, latest) != 0 {
+			continue
+		}
+		selected = append(selected, note)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return compareSemver(selected[i].Version, selected[j].Version) > 0
+	})
+	return selected
+}
+
+// parseReleaseJSON accepts either a list of releases or a single release
+// object.
+func parseReleaseJSON(data []byte) []ReleaseNote {
+	var notes []ReleaseNote
+	if err := json.Unmarshal(data, &notes); err != nil {
+		var single ReleaseNote
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil
+		}
+		notes = []ReleaseNote{single}
+	}
+	for i := range notes {
+		notes[i].Version = normalizeNoteVersion(notes[i].Version)
+	}
+	return notes
+}
+
+// parseChangelog splits a Keep a Changelog style file on its "## " release
+// headings ("## [1.2.0] - 2026-01-31", "## v1.2.0 (2026-01-31)").
+// Headings without a version, such as "## [Unreleased]", are skipped.
+func parseChangelog(content string) []ReleaseNote {
+	var notes []ReleaseNote
+	var current *ReleaseNote
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Notes = strings.TrimSpace(strings.Join(body, "\n"))
+			notes = append(notes, *current)
+		}
+		current = nil
+		body = nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			fields := strings.Fields(strings.NewReplacer("[", " ", "]", " ", "(", " ", ")", " ", " - ", " ").Replace(line[3:]))
+			if len(fields) == 0 {
+				continue
+			}
+			version := normalizeNoteVersion(fields[0])
+			if !isValidSemver(version) {
+				continue
+			}
+			current = &ReleaseNote{Version: version}
+			if len(fields) > 1 {
+				current.Date = fields[len(fields)-1]
+			}
+			continue
+		}
+		if current != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return notes
+}
+
+func normalizeNoteVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/inconshreveable/go-update"
+)
+
+// buildPatchFileName names the bsdiff patch from one release to another,
+// e.g. domour-copilot_v1.2.0_to_v1.3.0_linux_amd64.patch.
+func buildPatchFileName(from string, to string) string {
+	return fmt.Sprintf("domour-copilot_%s_to_%s_%s_%s.patch", from, to, runtime.GOOS, runtime.GOARCH)
+}
+
+// buildBinaryChecksumName is the checksums.txt entry for the unpacked binary
+// of a release, which a patched binary must match.
+func buildBinaryChecksumName(version string) string {
+	name := fmt.Sprintf("domour-copilot_%s_%s_%s", version, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// errPatchUnavailable means the release has no usable patch from the running
+// version, so the full archive is needed.
+var errPatchUnavailable = fmt.Errorf("no patch available")
+
+// applyPatchUpdate updates to version with a bsdiff patch from appVersion.
+// It needs both the patch and the resulting binary listed in the signed
+// checksums.txt. The running binary is only replaced once the patched
+// result matches, so any error other than a failed restore leaves it
+// untouched and the caller can fall back to the full archive.
+func (a *App) applyPatchUpdate(ctx context.Context, baseURL string, checksums string, version string) error {
+	if !isValidSemver(appVersion) || appVersion == version {
+		return errPatchUnavailable
+	}
+	sums := parseChecksums(checksums)
+	patchName := buildPatchFileName(appVersion, version)
+	if _, ok := sums[patchName]; !ok {
+		return errPatchUnavailable
+	}
+	target, err := hex.DecodeString(sums[buildBinaryChecksumName(version)])
+	if err != nil || len(target) == 0 {
+		return errPatchUnavailable
+	}
+
+	patchPath, err := downloadReleaseFile(ctx, baseURL, patchName, a.emitDownloadProgress)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(patchPath)
+	if err := verifyFileChecksum(checksums, patchName, patchPath); err != nil {
+		return err
+	}
+	patch, err := os.Open(patchPath)
+	if err != nil {
+		return err
+	}
+	defer patch.Close()
+
+	err = update.Apply(patch, update.Options{
+		Patcher:  update.NewBSDiffPatcher(),
+		Checksum: target,
+	})
+	if err != nil {
+		if rollbackErr := update.RollbackError(err); rollbackErr != nil {
+			return &patchRestoreError{err: rollbackErr}
+		}
+		return fmt.Errorf("failed to apply patch %s: %w", patchName, err)
+	}
+	return nil
+}
+
+// patchRestoreError means the binary was being replaced and could not be
+// restored; falling back to another update would make things worse.
+type patchRestoreError struct {
+	err error
+}
+
+func (e *patchRestoreError) Error() string {
+	return fmt.Sprintf("update failed and rollback failed: %v", e.err)
+}
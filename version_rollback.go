@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/go-update"
+)
+
+const (
+	// versionHistoryLimit is how many previous binaries stay under
+	// ~/.domour/versions.
+	versionHistoryLimit = 3
+	// startupCrashLimit consecutive launches of the same version that never
+	// reach startupGracePeriod trigger an automatic rollback.
+	startupCrashLimit   = 3
+	startupGracePeriod  = 30 * time.Second
+	startupSentinelName = "startup.json"
+)
+
+// InstalledVersion is a binary kept for rollback.
+type InstalledVersion struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	SavedAt int64  `json:"savedAt"`
+	Size    int64  `json:"size"`
+}
+
+// startupSentinel counts launches of Version that have not yet survived
+// startupGracePeriod.
+type startupSentinel struct {
+	Version  string `json:"version"`
+	Attempts int    `json:"attempts"`
+}
+
+func versionsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "versions"), nil
+}
+
+// ListInstalledVersions returns the binaries available to RollbackTo, newest
+// first.
+func (a *App) ListInstalledVersions() ([]InstalledVersion, error) {
+	return listInstalledVersions()
+}
+
+// RollbackTo replaces the running binary with a previously kept version. The
+// binary being replaced is kept as well, so the rollback can be undone.
+func (a *App) RollbackTo(version string) (string, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return "", fmt.Errorf("version is required")
+	}
+	if version == appVersion {
+		return "", fmt.Errorf("%s is already running", version)
+	}
+	if err := rollbackTo(version, true); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rolled back to %s, please restart the app", version), nil
+}
+
+func listInstalledVersions() ([]InstalledVersion, error) {
+	dir, err := versionsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []InstalledVersion{}, nil
+		}
+		return nil, err
+	}
+	versions := []InstalledVersion{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name(), executableName())
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, InstalledVersion{
+			Version: entry.Name(),
+			Path:    path,
+			SavedAt: info.ModTime().UnixMilli(),
+			Size:    info.Size(),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].SavedAt > versions[j].SavedAt
+	})
+	return versions, nil
+}
+
+// currentExecutable is the running binary with symlinks resolved, which is
+// the file an update replaces.
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// executableName is the file name kept copies are stored under.
+func executableName() string {
+	exe, err := currentExecutable()
+	if err != nil {
+		return "domour-copilot"
+	}
+	return filepath.Base(exe)
+}
+
+// keepCurrentVersion copies the running binary to ~/.domour/versions/<version>
+// before it is replaced, then prunes the oldest copies beyond
+// versionHistoryLimit, never pruning protect (the version being rolled back
+// to, if any). Builds without a release version are not kept.
+func keepCurrentVersion(protect string) error {
+	if !isValidSemver(appVersion) {
+		return nil
+	}
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	dir, err := versionsDir()
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, appVersion, executableName())
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := copyFile(exe, target, 0o755); err != nil {
+		return fmt.Errorf("failed to keep version %s: %w", appVersion, err)
+	}
+	// Refresh the mtime so a re-kept version counts as the newest.
+	now := time.Now()
+	_ = os.Chtimes(target, now, now)
+	return pruneInstalledVersions(protect)
+}
+
+func pruneInstalledVersions(protect string) error {
+	versions, err := listInstalledVersions()
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, v := range versions {
+		if v.Version == protect {
+			continue
+		}
+		if kept < versionHistoryLimit {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Dir(v.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src string, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// rollbackTo installs a kept version. keepCurrent keeps the binary being
+// replaced so the rollback can be undone; an automatic rollback passes false
+// so a build known to crash neither becomes a candidate nor evicts good
+// copies.
+func rollbackTo(version string, keepCurrent bool) error {
+	versions, err := listInstalledVersions()
+	if err != nil {
+		return err
+	}
+	var source string
+	for _, v := range versions {
+		if v.Version == version {
+			source = v.Path
+			break
+		}
+	}
+	if source == "" {
+		return fmt.Errorf("version %s is not kept locally", version)
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if keepCurrent {
+		if err := keepCurrentVersion(version); err != nil {
+			return err
+		}
+	}
+	if err := update.Apply(file, update.Options{}); err != nil {
+		if rollbackErr := update.RollbackError(err); rollbackErr != nil {
+			return fmt.Errorf("rollback failed and could not restore the current binary: %v", rollbackErr)
+		}
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+	return nil
+}
+
+func startupSentinelPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", startupSentinelName), nil
+}
+
+// checkStartupCrashes runs before the UI starts. It records this launch in
+// the startup sentinel, and once the same version has failed to survive
+// startupCrashLimit launches in a row it rolls back to the highest kept
+// older version and relaunches. It reports whether the caller should exit.
+func checkStartupCrashes() bool {
+	if !isValidSemver(appVersion) {
+		return false
+	}
+	path, err := startupSentinelPath()
+	if err != nil {
+		return false
+	}
+	var sentinel startupSentinel
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &sentinel)
+	}
+	if sentinel.Version != appVersion {
+		sentinel = startupSentinel{Version: appVersion}
+	}
+	sentinel.Attempts++
+
+	if sentinel.Attempts > startupCrashLimit {
+		if target := rollbackCandidate(); target != "" {
+			if err := rollbackTo(target, false); err == nil {
+				_ = os.Remove(path)
+				return relaunch()
+			}
+		}
+	}
+
+	data, err := json.Marshal(sentinel)
+	if err != nil {
+		return false
+	}
+	_ = os.MkdirAll(filepath.Dir(path), 0o755)
+	_ = writeFileAtomic(path, data, 0o644)
+	return false
+}
+
+// rollbackCandidate is the highest kept version below the running one. Newer
+// kept versions are skipped: they are builds that were rolled back from, so
+// when an older build crash-loops in turn the rollback keeps going down
+// instead of bouncing between two broken versions.
+func rollbackCandidate() string {
+	versions, err := listInstalledVersions()
+	if err != nil {
+		return ""
+	}
+	candidate := ""
+	for _, v := range versions {
+		if !isValidSemver(v.Version) || compareSemver(v.Version, appVersion) >= 0 {
+			continue
+		}
+		if candidate == "" || compareSemver(v.Version, candidate) > 0 {
+			candidate = v.Version
+		}
+	}
+	return candidate
+}
+
+func relaunch() bool {
+	exe, err := currentExecutable()
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Start() == nil
+}
+
+// clearStartupSentinel marks this launch as healthy once it has stayed up
+// for startupGracePeriod. A clean exit before then clears it too, see
+// shutdown.
+func (a *App) clearStartupSentinel() {
+	select {
+	case <-a.lifetime.Done():
+		return
+	case <-time.After(startupGracePeriod):
+	}
+	removeStartupSentinel()
+}
+
+func removeStartupSentinel() {
+	if path, err := startupSentinelPath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// keepFakeVersion stores a placeholder binary for version, kept at savedAt.
+func keepFakeVersion(t *testing.T, version string, savedAt time.Time) {
+	t.Helper()
+	dir, err := versionsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, version, executableName())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(version), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, savedAt, savedAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setAppVersion(t *testing.T, version string) {
+	t.Helper()
+	previous := appVersion
+	appVersion = version
+	t.Cleanup(func() { appVersion = previous })
+}
+
+func TestRollbackCandidateAcrossCrashLoops(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Now()
+	keepFakeVersion(t, "v1.8.0", now.Add(-3*time.Hour))
+	keepFakeVersion(t, "v1.9.0", now.Add(-2*time.Hour))
+	// v2.0.0 was rolled back from once already, which left it the newest
+	// kept copy.
+	keepFakeVersion(t, "v2.0.0", now)
+
+	steps := []struct {
+		running string
+		want    string
+	}{
+		{"v2.0.0", "v1.9.0"},
+		// v1.9.0 crash-loops too: go further down, not back to v2.0.0.
+		{"v1.9.0", "v1.8.0"},
+		{"v1.8.0", ""},
+		{"v2.1.0-beta.1", "v2.0.0"},
+	}
+	for _, step := range steps {
+		setAppVersion(t, step.running)
+		if got := rollbackCandidate(); got != step.want {
+			t.Errorf("running %s: candidate = %q, want %q", step.running, got, step.want)
+		}
+	}
+}
+
+func TestKeepCurrentVersionProtectsRollbackTarget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Now()
+	keepFakeVersion(t, "v1.6.0", now.Add(-4*time.Hour))
+	keepFakeVersion(t, "v1.7.0", now.Add(-3*time.Hour))
+	keepFakeVersion(t, "v1.8.0", now.Add(-2*time.Hour))
+	keepFakeVersion(t, "v1.9.0", now.Add(-time.Hour))
+
+	setAppVersion(t, "v2.0.0")
+	if err := keepCurrentVersion("v1.6.0"); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := listInstalledVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	want := []string{"v2.0.0", "v1.9.0", "v1.8.0", "v1.6.0"}
+	if len(got) != len(want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("kept %v, want %v", got, want)
+		}
+	}
+}
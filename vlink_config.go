@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	vlinkCheckTimeout = 10 * time.Second
+	// vlinkHelpTimeout bounds "vlink -h"; a build that does not understand
+	// -h may start serving instead of exiting.
+	vlinkHelpTimeout = 3 * time.Second
+)
+
+// vlinkCheckFlag matches the -check flag in vlink's usage output.
+var vlinkCheckFlag = regexp.MustCompile(`(?m)^\s*--?check\b`)
+
+// vlinkCheckSupport caches whether a vlink binary, identified by path, size
+// and modification time, supports -check.
+var vlinkCheckSupport struct {
+	sync.Mutex
+	key       string
+	supported bool
+}
+
+// Diagnostic severities and sources.
+const (
+	diagnosticError   = "error"
+	diagnosticWarning = "warning"
+
+	diagnosticSourceSyntax = "syntax"
+	diagnosticSourceSchema = "schema"
+	diagnosticSourceVlink  = "vlink"
+)
+
+// VlinkConfigDiagnostic is one problem in a vlink config. Line and Column
+// are 1-based, with columns counted in UTF-16 units as the editor does.
+type VlinkConfigDiagnostic struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+// VlinkConfigValidation is the result of validating, and possibly saving, a
+// vlink config. Checked reports whether vlink itself dry-ran the config.
+type VlinkConfigValidation struct {
+	Valid       bool                    `json:"valid"`
+	Saved       bool                    `json:"saved"`
+	Checked     bool                    `json:"checked"`
+	Diagnostics []VlinkConfigDiagnostic `json:"diagnostics"`
+}
+
+// ValidateVlinkConfig checks content without saving it. With dryRun, the
+// installed vlink also checks it via "-check" when it supports that flag.
+func (a *App) ValidateVlinkConfig(content string, dryRun bool) (VlinkConfigValidation, error) {
+	return validateVlinkConfig(content, dryRun), nil
+}
+
+func validateVlinkConfig(content string, dryRun bool) VlinkConfigValidation {
+	result := VlinkConfigValidation{Diagnostics: []VlinkConfigDiagnostic{}}
+	// Unmarshal gives the clearest syntax errors; the token walk below only
+	// runs on valid JSON.
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		result.Diagnostics = append(result.Diagnostics, syntaxDiagnostic(content, err))
+		return result
+	}
+	positions, err := jsonValuePositions([]byte(content))
+	if err != nil {
+		result.Diagnostics = append(result.Diagnostics, syntaxDiagnostic(content, err))
+		return result
+	}
+
+	var root interface{}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		result.Diagnostics = append(result.Diagnostics, syntaxDiagnostic(content, err))
+		return result
+	}
+	locate := func(path string) (int, int) {
+		return offsetToLineColumn(content, positions[path])
+	}
+	result.Diagnostics = append(result.Diagnostics, checkVlinkConfigSchema(root, locate)...)
+
+	if dryRun && !hasErrorDiagnostics(result.Diagnostics) {
+		checked, diagnostics := dryRunVlinkConfig(content)
+		result.Checked = checked
+		result.Diagnostics = append(result.Diagnostics, diagnostics...)
+	}
+	result.Valid = !hasErrorDiagnostics(result.Diagnostics)
+	return result
+}
+
+func hasErrorDiagnostics(diagnostics []VlinkConfigDiagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == diagnosticError {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVlinkConfigSchema checks the structure vlink relies on: inbounds with
+// valid, distinct ports and outbounds with a protocol. Missing sections are
+// only warnings since vlink falls back to its defaults.
+func checkVlinkConfigSchema(root interface{}, locate func(path string) (int, int)) []VlinkConfigDiagnostic {
+	var diagnostics []VlinkConfigDiagnostic
+	report := func(severity string, path string, format string, args ...interface{}) {
+		line, column := locate(path)
+		diagnostics = append(diagnostics, VlinkConfigDiagnostic{
+			Line:     line,
+			Column:   column,
+			Severity: severity,
+			Source:   diagnosticSourceSchema,
+			Path:     path,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	config, ok := root.(map[string]interface{})
+	if !ok {
+		report(diagnosticError, "", "config must be a JSON object")
+		return diagnostics
+	}
+
+	inbounds, present, ok := jsonArrayField(config, "inbounds")
+	switch {
+	case !present:
+		report(diagnosticWarning, "", "config has no inbounds; vlink will use its default local proxy")
+	case !ok:
+		report(diagnosticError, "inbounds", "inbounds must be an array")
+	}
+	ports := make(map[int64]string)
+	for i, item := range inbounds {
+		path := fmt.Sprintf("inbounds[%d]", i)
+		inbound, ok := item.(map[string]interface{})
+		if !ok {
+			report(diagnosticError, path, "inbound must be an object")
+			continue
+		}
+		checkOptionalString(inbound, path, "protocol", report)
+		checkOptionalString(inbound, path, "listen", report)
+		checkOptionalString(inbound, path, "tag", report)
+		portPath := path + ".port"
+		raw, ok := inbound["port"]
+		if !ok {
+			report(diagnosticError, path, "inbound is missing port")
+			continue
+		}
+		port, err := jsonPort(raw)
+		if err != nil {
+			report(diagnosticError, portPath, "%v", err)
+			continue
+		}
+		if first, dup := ports[port]; dup {
+			report(diagnosticError, portPath, "port %d is already used by %s", port, first)
+			continue
+		}
+		ports[port] = path
+	}
+
+	outbounds, present, ok := jsonArrayField(config, "outbounds")
+	switch {
+	case !present:
+		report(diagnosticWarning, "", "config has no outbounds; traffic will go out directly")
+	case !ok:
+		report(diagnosticError, "outbounds", "outbounds must be an array")
+	case len(outbounds) == 0:
+		report(diagnosticWarning, "outbounds", "outbounds is empty; traffic will go out directly")
+	}
+	tags := make(map[string]string)
+	for i, item := range outbounds {
+		path := fmt.Sprintf("outbounds[%d]", i)
+		outbound, ok := item.(map[string]interface{})
+		if !ok {
+			report(diagnosticError, path, "outbound must be an object")
+			continue
+		}
+		protocol, ok := outbound["protocol"].(string)
+		if !ok || strings.TrimSpace(protocol) == "" {
+			report(diagnosticError, path, "outbound is missing protocol")
+		}
+		if raw, ok := outbound["settings"]; ok {
+			if _, isObject := raw.(map[string]interface{}); !isObject {
+				report(diagnosticError, path+".settings", "settings must be an object")
+			}
+		}
+		tag, isString := checkOptionalString(outbound, path, "tag", report)
+		if isString && tag != "" {
+			if first, dup := tags[tag]; dup {
+				report(diagnosticWarning, path+".tag", "tag %q is already used by %s", tag, first)
+			} else {
+				tags[tag] = path
+			}
+		}
+	}
+	return diagnostics
+}
+
+func jsonArrayField(object map[string]interface{}, key string) (items []interface{}, present bool, ok bool) {
+	raw, present := object[key]
+	if !present {
+		return nil, false, false
+	}
+	items, ok = raw.([]interface{})
+	return items, true, ok
+}
+
+func checkOptionalString(object map[string]interface{}, path string, key string, report func(string, string, string, ...interface{})) (string, bool) {
+	raw, ok := object[key]
+	if !ok {
+		return "", false
+	}
+	value, ok := raw.(string)
+	if !ok {
+		report(diagnosticError, path+"."+key, "%s must be a string", key)
+		return "", false
+	}
+	return value, true
+}
+
+// jsonPort accepts a port as a number or a numeric string.
+func jsonPort(raw interface{}) (int64, error) {
+	var text string
+	switch v := raw.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return 0, fmt.Errorf("port must be a number")
+	}
+	port, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("port %q is not an integer", text)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is out of range 1-65535", port)
+	}
+	return port, nil
+}
+
+// jsonValuePositions maps paths like "inbounds[0].port" to the byte offset
+// where that value starts. The root is "". data must already be valid JSON.
+func jsonValuePositions(data []byte) (map[string]int64, error) {
+	positions := make(map[string]int64)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := walkJSONValue(decoder, data, "", positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+func walkJSONValue(decoder *json.Decoder, data []byte, path string, positions map[string]int64) error {
+	positions[path] = skipJSONSeparators(data, decoder.InputOffset())
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+	switch delim {
+	case '{':
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyToken.(string)
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := walkJSONValue(decoder, data, child, positions); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; decoder.More(); i++ {
+			if err := walkJSONValue(decoder, data, fmt.Sprintf("%s[%d]", path, i), positions); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// skipJSONSeparators moves offset past whitespace, commas and colons so it
+// points at the next value rather than the end of the previous token.
+func skipJSONSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func offsetToLineColumn(content string, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	line, column := 1, 1
+	for _, r := range content[:offset] {
+		if r == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		if r == utf8.RuneError {
+			column++
+			continue
+		}
+		column += utf16.RuneLen(r)
+	}
+	return line, column
+}
+
+func syntaxDiagnostic(content string, err error) VlinkConfigDiagnostic {
+	var offset int64 = int64(len(content))
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset is just past the offending byte.
+		offset = syntaxErr.Offset - 1
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	message := err.Error()
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		message = "unexpected end of JSON input"
+	}
+	line, column := offsetToLineColumn(content, offset)
+	return VlinkConfigDiagnostic{
+		Line:     line,
+		Column:   column,
+		Severity: diagnosticError,
+		Source:   diagnosticSourceSyntax,
+		Message:  message,
+	}
+}
+
+// dryRunVlinkConfig runs "vlink -config <tmp> -check". It reports whether
+// the check ran; a missing binary or one without the flag is skipped.
+func dryRunVlinkConfig(content string) (bool, []VlinkConfigDiagnostic) {
+	binaryPath, err := vlinkBinaryPath()
+	if err != nil || !vlinkSupportsCheck(binaryPath) {
+		return false, nil
+	}
+	tmp, err := os.CreateTemp("", "vlink-config-*.json")
+	if err != nil {
+		return false, nil
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return false, nil
+	}
+	if err := tmp.Close(); err != nil {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vlinkCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binaryPath, "-config", tmp.Name(), "-check")
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err == nil {
+		return true, nil
+	}
+	if ctx.Err() != nil {
+		text = fmt.Sprintf("vlink -check did not finish within %s", vlinkCheckTimeout)
+	}
+	if text == "" {
+		text = err.Error()
+	}
+	return true, []VlinkConfigDiagnostic{{
+		Line:     1,
+		Column:   1,
+		Severity: diagnosticError,
+		Source:   diagnosticSourceVlink,
+		Message:  text,
+	}}
+}
+
+// vlinkSupportsCheck reports whether the vlink at binaryPath lists -check in
+// its "-h" output. The answer is cached until the binary changes, so saves
+// do not pay for the probe, and a vlink without -check is never started with
+// a config it would go on to serve.
+func vlinkSupportsCheck(binaryPath string) bool {
+	info, err := os.Stat(binaryPath)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	key := fmt.Sprintf("%s|%d|%d", binaryPath, info.Size(), info.ModTime().UnixNano())
+
+	vlinkCheckSupport.Lock()
+	defer vlinkCheckSupport.Unlock()
+	if vlinkCheckSupport.key == key {
+		return vlinkCheckSupport.supported
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vlinkHelpTimeout)
+	defer cancel()
+	// Go's flag package exits with status 0 or 2 after printing usage, so
+	// only the output matters.
+	cmd := exec.CommandContext(ctx, binaryPath, "-h")
+	cmd.WaitDelay = time.Second
+	output, _ := cmd.CombinedOutput()
+	vlinkCheckSupport.key = key
+	vlinkCheckSupport.supported = ctx.Err() == nil && vlinkCheckFlag.Match(output)
+	return vlinkCheckSupport.supported
+}
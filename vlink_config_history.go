@@ -0,0 +1,456 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// vlinkConfigHistoryLimit caps the snapshots kept; the oldest go first.
+	vlinkConfigHistoryLimit = 50
+	// vlinkConfigCurrent names the config on disk in DiffVlinkConfig.
+	vlinkConfigCurrent = "current"
+	diffContextLines   = 3
+)
+
+// VlinkConfigVersion describes one saved snapshot of the vlink config.
+type VlinkConfigVersion struct {
+	ID      string `json:"id"`
+	SavedAt int64  `json:"savedAt"`
+	Comment string `json:"comment"`
+	Size    int    `json:"size"`
+}
+
+type vlinkConfigSnapshot struct {
+	VlinkConfigVersion
+	Content string `json:"content"`
+}
+
+// ListVlinkConfigVersions returns saved config snapshots, newest first.
+func (a *App) ListVlinkConfigVersions() ([]VlinkConfigVersion, error) {
+	snapshots, err := listVlinkConfigSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]VlinkConfigVersion, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		versions = append(versions, snapshot.VlinkConfigVersion)
+	}
+	return versions, nil
+}
+
+// DiffVlinkConfig returns a unified diff from version from to version to.
+// Either may be "current" (or empty) for the config on disk.
+func (a *App) DiffVlinkConfig(from string, to string) (string, error) {
+	before, err := vlinkConfigContent(from)
+	if err != nil {
+		return "", err
+	}
+	after, err := vlinkConfigContent(to)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(vlinkConfigLabel(from), vlinkConfigLabel(to), before, after), nil
+}
+
+// RestoreVlinkConfig saves a snapshot's content as the current config. It
+// goes through the same validation as SaveVlinkConfig and is itself
+// recorded as a new snapshot.
+func (a *App) RestoreVlinkConfig(version string) (VlinkConfigValidation, error) {
+	snapshot, err := loadVlinkConfigSnapshot(version)
+	if err != nil {
+		return VlinkConfigValidation{}, err
+	}
+	a.vlinkConfigMu.Lock()
+	defer a.vlinkConfigMu.Unlock()
+	return a.saveVlinkConfig(snapshot.Content, "restored from "+snapshot.ID)
+}
+
+// saveVlinkConfig validates content, writes it atomically and snapshots it.
+// The active profile, if any, is kept in sync. Callers hold vlinkConfigMu.
+func (a *App) saveVlinkConfig(content string, comment string) (VlinkConfigValidation, error) {
+	result := validateVlinkConfig(content, true)
+	if !result.Valid {
+		return result, nil
+	}
+	path, _, err := ensureVlinkHomeConfig()
+	if err != nil {
+		return result, err
+	}
+	// Configs written before history existed would otherwise be lost on
+	// the first tracked save.
+	if snapshots, err := listVlinkConfigSnapshots(); err == nil && len(snapshots) == 0 {
+		if previous, err := os.ReadFile(path); err == nil && string(previous) != content {
+			_ = snapshotVlinkConfig(string(previous), "before first tracked save")
+		}
+	}
+	if err := writeFileAtomic(path, []byte(content), 0o600); err != nil {
+		return result, err
+	}
+	result.Saved = true
+	if err := a.syncActiveVlinkProfile(content); err != nil {
+		return result, fmt.Errorf("config saved but profile update failed: %w", err)
+	}
+	if err := snapshotVlinkConfig(content, comment); err != nil {
+		return result, fmt.Errorf("config saved but snapshot failed: %w", err)
+	}
+	return result, nil
+}
+
+func vlinkConfigHistoryDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "vlink-config"), nil
+}
+
+func snapshotVlinkConfig(content string, comment string) error {
+	dir, err := vlinkConfigHistoryDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	savedAt := time.Now().UnixMilli()
+	id := strconv.FormatInt(savedAt, 10)
+	for fileExists(filepath.Join(dir, id+".json")) {
+		savedAt++
+		id = strconv.FormatInt(savedAt, 10)
+	}
+	snapshot := vlinkConfigSnapshot{
+		VlinkConfigVersion: VlinkConfigVersion{
+			ID:      id,
+			SavedAt: savedAt,
+			Comment: strings.TrimSpace(comment),
+			Size:    len(content),
+		},
+		Content: content,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, id+".json"), data, 0o600); err != nil {
+		return err
+	}
+	return pruneVlinkConfigSnapshots()
+}
+
+func listVlinkConfigSnapshots() ([]vlinkConfigSnapshot, error) {
+	dir, err := vlinkConfigHistoryDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []vlinkConfigSnapshot
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		snapshot, err := loadVlinkConfigSnapshot(id)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].SavedAt > snapshots[j].SavedAt
+	})
+	return snapshots, nil
+}
+
+func loadVlinkConfigSnapshot(id string) (vlinkConfigSnapshot, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return vlinkConfigSnapshot{}, fmt.Errorf("invalid config version %q", id)
+	}
+	dir, err := vlinkConfigHistoryDir()
+	if err != nil {
+		return vlinkConfigSnapshot{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vlinkConfigSnapshot{}, fmt.Errorf("config version %s not found", id)
+		}
+		return vlinkConfigSnapshot{}, err
+	}
+	var snapshot vlinkConfigSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return vlinkConfigSnapshot{}, fmt.Errorf("failed to parse config version %s: %w", id, err)
+	}
+	snapshot.ID = id
+	return snapshot, nil
+}
+
+func pruneVlinkConfigSnapshots() error {
+	snapshots, err := listVlinkConfigSnapshots()
+	if err != nil || len(snapshots) <= vlinkConfigHistoryLimit {
+		return err
+	}
+	dir, err := vlinkConfigHistoryDir()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots[vlinkConfigHistoryLimit:] {
+		if err := os.Remove(filepath.Join(dir, snapshot.ID+".json")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func vlinkConfigContent(version string) (string, error) {
+	if version == "" || version == vlinkConfigCurrent {
+		path, err := vlinkHomeConfigPath()
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		return string(data), nil
+	}
+	snapshot, err := loadVlinkConfigSnapshot(version)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.Content, nil
+}
+
+func vlinkConfigLabel(version string) string {
+	if version == "" || version == vlinkConfigCurrent {
+		return vlinkConfigCurrent
+	}
+	return version
+}
+
+// unifiedDiff renders a line diff of before and after in unified format with
+// diffContextLines of context. Identical inputs give an empty string.
+func unifiedDiff(fromLabel string, toLabel string, before string, after string) string {
+	lines := diffLines(splitDiffLines(before), splitDiffLines(after))
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while changes are within two context windows.
+		first := max(start-diffContextLines, 0)
+		end := start
+		for k := start; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				end = k
+			} else if k-end > 2*diffContextLines {
+				break
+			}
+		}
+		last := min(end+diffContextLines, len(lines)-1)
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+		}
+		aCount, bCount := 0, 0
+		for _, line := range lines[first : last+1] {
+			if line.op != '+' {
+				aCount++
+			}
+			if line.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lines[first].a, aCount), hunkRange(lines[first].b, bCount))
+		for _, line := range lines[first : last+1] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+		start = last + 1
+	}
+	return out.String()
+}
+
+type diffLine struct {
+	op   byte
+	text string
+	a, b int // line indexes before this line in a and b
+}
+
+// diffLines returns the edit script turning a into b. It uses Myers'
+// linear-space divide and conquer, so memory stays proportional to the
+// input even for large configs.
+func diffLines(a []string, b []string) []diffLine {
+	d := &lineDiffer{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.lines
+}
+
+type lineDiffer struct {
+	a, b  []string
+	lines []diffLine
+}
+
+func (d *lineDiffer) emit(op byte, i int, j int) {
+	text := ""
+	if op == '+' {
+		text = d.b[j]
+	} else {
+		text = d.a[i]
+	}
+	d.lines = append(d.lines, diffLine{op, text, i, j})
+}
+
+// compare appends the edit script for a[aLo:aHi] to b[bLo:bHi].
+func (d *lineDiffer) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.emit(' ', aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	x, y, ok := d.bisect(aLo, aHi, bLo, bHi)
+	switch {
+	case aLo == aHi || bLo == bHi || !ok:
+		for i := aLo; i < aHi; i++ {
+			d.emit('-', i, bLo)
+		}
+		for j := bLo; j < bHi; j++ {
+			d.emit('+', aHi, j)
+		}
+	default:
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+
+	for k := 0; k < suffix; k++ {
+		d.emit(' ', aHi+k, bHi+k)
+	}
+}
+
+// bisect finds where the forward and reverse searches for the shortest
+// edit script of a[aLo:aHi] and b[bLo:bHi] meet, and returns that point as
+// a split for compare. ok is false when the ranges share nothing or no
+// split strictly inside them exists.
+func (d *lineDiffer) bisect(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	reverse := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+	delta := n - m
+	// With an odd delta the paths meet while extending forward.
+	odd := delta%2 != 0
+	split := func(x, y int) (int, int, bool) {
+		if (x == 0 && y == 0) || (x == n && y == m) {
+			return 0, 0, false
+		}
+		return aLo + x, bLo + y, true
+	}
+
+	fStart, fEnd, rStart, rEnd := 0, 0, 0, 0
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				r := offset + delta - k
+				if r >= 0 && r < len(reverse) && reverse[r] != -1 && x >= n-reverse[r] {
+					return split(x, y)
+				}
+			}
+		}
+		for k := -step + rStart; k <= step-rEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && reverse[i-1] < reverse[i+1]) {
+				x = reverse[i+1]
+			} else {
+				x = reverse[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			reverse[i] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				f := offset + delta - k
+				if f >= 0 && f < len(forward) && forward[f] != -1 {
+					fx := forward[f]
+					if fx >= n-x {
+						return split(fx, offset+fx-f)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitDiffLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
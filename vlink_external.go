@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// vlinkAdoptPollInterval is how often an adopted vlink is checked for
+	// having exited.
+	vlinkAdoptPollInterval = 2 * time.Second
+	vlinkStopTimeout       = 3 * time.Second
+)
+
+// How an external vlink was found.
+const (
+	externalVlinkSourcePIDFile = "pidfile"
+	externalVlinkSourcePort    = "port"
+)
+
+// vlinkPIDFile is the content of ~/.vlink/vlink.pid, written for every vlink
+// the app runs so a later instance can find it.
+type vlinkPIDFile struct {
+	PID       int    `json:"pid"`
+	Binary    string `json:"binary"`
+	StartedAt int64  `json:"startedAt"`
+}
+
+// ExternalVlink is a vlink, or another program holding vlink's SOCKS port,
+// that the app did not start. It is also the payload of vlink:conflict.
+type ExternalVlink struct {
+	Found bool `json:"found"`
+	// PID is 0 when the port is busy but its owner could not be found.
+	PID     int    `json:"pid"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Source  string `json:"source"`
+	// IsVlink is false when the port belongs to some other program, which
+	// can be stopped but not adopted.
+	IsVlink bool `json:"isVlink"`
+}
+
+// FindExternalVlink looks for a vlink the app is not managing, first through
+// the PID file and then by the owner of the SOCKS port.
+func (a *App) FindExternalVlink() ExternalVlink {
+	a.vlinkMu.Lock()
+	managed := a.vlinkStop != nil || a.vlinkAdopted != 0
+	a.vlinkMu.Unlock()
+	if managed {
+		return ExternalVlink{}
+	}
+	socks, _ := a.vlinkProxyEndpoints()
+	return findExternalVlink(socks.Address)
+}
+
+// AdoptVlink takes over an external vlink: it is reported as running and
+// StopVlink stops it. The app watches it but cannot restart it, since it
+// did not start it.
+func (a *App) AdoptVlink() (string, error) {
+	external := a.FindExternalVlink()
+	if !external.Found {
+		return "", fmt.Errorf("no external vlink found")
+	}
+	if !external.IsVlink || external.PID == 0 {
+		return "", fmt.Errorf("port %s is held by %s, which is not vlink", external.Address, externalVlinkLabel(external))
+	}
+
+	a.vlinkMu.Lock()
+	if a.vlinkStop != nil || a.vlinkAdopted != 0 {
+		a.vlinkMu.Unlock()
+		return "vlink is already running", nil
+	}
+	a.vlinkAdopted = external.PID
+	a.setVlinkStateLocked(VlinkState{State: vlinkStateRunning, PID: external.PID, Message: "adopted"})
+	a.vlinkMu.Unlock()
+
+	_ = writeVlinkPIDFile(external.PID, "")
+	go a.watchAdoptedVlink(external.PID)
+	return "vlink adopted", nil
+}
+
+// StopExternalVlink stops whatever FindExternalVlink reports, vlink or not,
+// so the app can start its own.
+func (a *App) StopExternalVlink() (string, error) {
+	external := a.FindExternalVlink()
+	if !external.Found {
+		return "no external vlink found", nil
+	}
+	if external.PID == 0 {
+		return "", fmt.Errorf("cannot find the process holding port %s", external.Address)
+	}
+	if err := stopProcess(external.PID); err != nil {
+		return "", err
+	}
+	if external.Source == externalVlinkSourcePIDFile {
+		removeVlinkPIDFile()
+	}
+	return "external vlink stopped", nil
+}
+
+// checkVlinkConflict reports an external vlink, or another owner of the
+// SOCKS port, as a vlink:conflict event and an error so StartVlink does not
+// launch a copy that would fail to bind. ignorePID is skipped, for the
+// systemd service's own process.
+func (a *App) checkVlinkConflict(ignorePID int) error {
+	socks, _ := a.vlinkProxyEndpoints()
+	external := findExternalVlink(socks.Address)
+	if !external.Found || (ignorePID != 0 && external.PID == ignorePID) {
+		return nil
+	}
+	if a.ctx != nil {
+		wailsruntime.EventsEmit(a.ctx, "vlink:conflict", external)
+	}
+	if external.IsVlink {
+		return fmt.Errorf("vlink conflict: vlink is already running outside the app (%s)", externalVlinkLabel(external))
+	}
+	return fmt.Errorf("vlink conflict: port %s is used by %s", external.Address, externalVlinkLabel(external))
+}
+
+// stopAdoptedVlink stops an adopted vlink. It reports false when there is
+// none.
+func (a *App) stopAdoptedVlink() (bool, error) {
+	a.vlinkMu.Lock()
+	pid := a.vlinkAdopted
+	a.vlinkAdopted = 0
+	a.vlinkMu.Unlock()
+	if pid == 0 {
+		return false, nil
+	}
+	if err := stopProcess(pid); err != nil {
+		return true, err
+	}
+	removeVlinkPIDFile()
+	a.setVlinkState(VlinkState{State: vlinkStateStopped})
+	return true, nil
+}
+
+func (a *App) watchAdoptedVlink(pid int) {
+	ticker := time.NewTicker(vlinkAdoptPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.vlinkMu.Lock()
+		if a.vlinkAdopted != pid {
+			a.vlinkMu.Unlock()
+			return
+		}
+		if !processAlive(pid) {
+			a.vlinkAdopted = 0
+			a.setVlinkStateLocked(VlinkState{State: vlinkStateStopped, ExitCode: vlinkUnknownExitCode, Message: "adopted vlink exited"})
+			a.vlinkMu.Unlock()
+			removeVlinkPIDFile()
+			return
+		}
+		a.vlinkMu.Unlock()
+	}
+}
+
+// findExternalVlink checks the PID file, then who owns socksAddr.
+func findExternalVlink(socksAddr string) ExternalVlink {
+	if record, err := readVlinkPIDFile(); err == nil {
+		if processAlive(record.PID) && isVlinkProcessName(processName(record.PID)) {
+			return ExternalVlink{
+				Found:   true,
+				PID:     record.PID,
+				Name:    processName(record.PID),
+				Address: socksAddr,
+				Source:  externalVlinkSourcePIDFile,
+				IsVlink: true,
+			}
+		}
+		// The process is gone; the file is left over from a crash.
+		removeVlinkPIDFile()
+	}
+
+	conn, err := net.DialTimeout("tcp", socksAddr, 500*time.Millisecond)
+	if err != nil {
+		return ExternalVlink{}
+	}
+	_ = conn.Close()
+	external := ExternalVlink{Found: true, Address: socksAddr, Source: externalVlinkSourcePort}
+	if _, port, err := net.SplitHostPort(socksAddr); err == nil {
+		if n, err := strconv.Atoi(port); err == nil {
+			external.PID = portOwnerPID(n)
+		}
+	}
+	if external.PID != 0 {
+		external.Name = processName(external.PID)
+		external.IsVlink = isVlinkProcessName(external.Name)
+	}
+	return external
+}
+
+func isVlinkProcessName(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	return name == "vlink"
+}
+
+func externalVlinkLabel(external ExternalVlink) string {
+	switch {
+	case external.PID == 0:
+		return "an unknown process"
+	case external.Name == "":
+		return fmt.Sprintf("pid %d", external.PID)
+	}
+	return fmt.Sprintf("%s, pid %d", external.Name, external.PID)
+}
+
+// stopProcess asks pid to exit and kills it after vlinkStopTimeout.
+func stopProcess(pid int) error {
+	if err := signalProcess(pid, false); err != nil && processAlive(pid) {
+		return fmt.Errorf("failed to stop pid %d: %w", pid, err)
+	}
+	deadline := time.Now().Add(vlinkStopTimeout)
+	for time.Now().Before(deadline) {
+		if !processAlive(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := signalProcess(pid, true); err != nil && processAlive(pid) {
+		return fmt.Errorf("failed to kill pid %d: %w", pid, err)
+	}
+	return nil
+}
+
+func vlinkPIDFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".vlink", "vlink.pid"), nil
+}
+
+func writeVlinkPIDFile(pid int, binary string) error {
+	path, err := vlinkPIDFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(vlinkPIDFile{PID: pid, Binary: binary, StartedAt: time.Now().UnixMilli()})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0o600)
+}
+
+func readVlinkPIDFile() (vlinkPIDFile, error) {
+	path, err := vlinkPIDFilePath()
+	if err != nil {
+		return vlinkPIDFile{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return vlinkPIDFile{}, err
+	}
+	var record vlinkPIDFile
+	if err := json.Unmarshal(data, &record); err != nil {
+		return vlinkPIDFile{}, err
+	}
+	return record, nil
+}
+
+func removeVlinkPIDFile() {
+	if path, err := vlinkPIDFilePath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// portOwnerPID returns the process listening on TCP port, or 0 if it cannot
+// be found.
+func portOwnerPID(port int) int {
+	switch runtime.GOOS {
+	case "linux":
+		return procNetPortOwner(port)
+	case "windows":
+		return netstatPortOwner(port)
+	}
+	output, err := exec.Command("lsof", "-nP", "-t", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN").Output()
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0]))
+	return pid
+}
+
+// procNetPortOwner finds the listening socket for port in /proc/net/tcp{,6}
+// and then the process holding that socket's inode. Only processes of the
+// same user can be inspected, which covers a vlink the user started.
+func procNetPortOwner(port int) int {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		for inode := range listeningInodes(table, port) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return 0
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0
+	}
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join("/proc", proc.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join("/proc", proc.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := strings.CutPrefix(link, "socket:["); ok && inodes[strings.TrimSuffix(inode, "]")] {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+// listeningInodes returns the inodes of sockets listening on port in a
+// /proc/net/tcp style table.
+func listeningInodes(table string, port int) map[string]bool {
+	inodes := make(map[string]bool)
+	file, err := os.Open(table)
+	if err != nil {
+		return inodes
+	}
+	defer file.Close()
+	const stateListen = "0A"
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != stateListen {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(hexPort, 16, 32); err == nil && int(n) == port {
+			inodes[fields[9]] = true
+		}
+	}
+	return inodes
+}
+
+// netstatPortOwner reads the owning PID of a listening port from
+// "netstat -ano".
+func netstatPortOwner(port int) int {
+	output, err := exec.Command("netstat", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return 0
+	}
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[3] != "LISTENING" || !strings.HasSuffix(fields[1], suffix) {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[4]); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
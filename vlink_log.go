@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	vlinkLogRingSize    = 2000
+	vlinkLogMaxFileSize = 5 << 20
+	vlinkLogBackups     = 3
+	vlinkLogMaxLineSize = 16 << 10
+)
+
+// VlinkLogLine is one line of vlink output, as returned by GetVlinkLogs and
+// emitted as vlink:log.
+type VlinkLogLine struct {
+	// Seq increases by one per line for the lifetime of the app and is the
+	// offset GetVlinkLogs pages by.
+	Seq    int64  `json:"seq"`
+	Time   int64  `json:"time"`
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+// VlinkLogPage is a page of buffered vlink log lines.
+type VlinkLogPage struct {
+	Lines []VlinkLogLine `json:"lines"`
+	// FirstSeq is the oldest line still buffered; NextSeq is the offset to
+	// request next.
+	FirstSeq int64  `json:"firstSeq"`
+	NextSeq  int64  `json:"nextSeq"`
+	Path     string `json:"path"`
+}
+
+// vlinkLogger keeps the most recent vlink output in memory and appends all of
+// it to a size-rotated file under ~/.domour/logs.
+type vlinkLogger struct {
+	mu      sync.Mutex
+	ring    []VlinkLogLine
+	nextSeq int64
+	path    string
+	file    *os.File
+	size    int64
+	emit    func(VlinkLogLine)
+}
+
+func newVlinkLogger(emit func(VlinkLogLine)) *vlinkLogger {
+	return &vlinkLogger{emit: emit}
+}
+
+// GetVlinkLogs returns up to limit buffered log lines starting at offset. An
+// offset older than the buffer starts at the oldest line still kept.
+func (a *App) GetVlinkLogs(offset int64, limit int) VlinkLogPage {
+	return a.vlinkLog.page(offset, limit)
+}
+
+func (a *App) emitVlinkLog(line VlinkLogLine) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, "vlink:log", line)
+}
+
+func vlinkLogFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "logs", "vlink.log"), nil
+}
+
+// writer returns an io.Writer that splits output into lines tagged with
+// stream. Each stream needs its own writer so partial lines don't interleave.
+func (l *vlinkLogger) writer(stream string) io.Writer {
+	return &vlinkLogWriter{logger: l, stream: stream}
+}
+
+// logf records a line generated by the app itself, e.g. supervisor events.
+func (l *vlinkLogger) logf(format string, args ...any) {
+	l.append("app", strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+func (l *vlinkLogger) append(stream string, text string) {
+	l.mu.Lock()
+	line := VlinkLogLine{
+		Seq:    l.nextSeq,
+		Time:   time.Now().UnixMilli(),
+		Stream: stream,
+		Text:   text,
+	}
+	l.nextSeq++
+	if len(l.ring) < vlinkLogRingSize {
+		l.ring = append(l.ring, line)
+	} else {
+		l.ring[line.Seq%vlinkLogRingSize] = line
+	}
+	l.writeFileLocked(line)
+	l.mu.Unlock()
+
+	if l.emit != nil {
+		l.emit(line)
+	}
+}
+
+func (l *vlinkLogger) page(offset int64, limit int) VlinkLogPage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	first := l.nextSeq - int64(len(l.ring))
+	if offset < first {
+		offset = first
+	}
+	if limit <= 0 || limit > vlinkLogRingSize {
+		limit = vlinkLogRingSize
+	}
+	lines := []VlinkLogLine{}
+	for seq := offset; seq < l.nextSeq && len(lines) < limit; seq++ {
+		lines = append(lines, l.ring[seq%vlinkLogRingSize])
+	}
+	return VlinkLogPage{
+		Lines:    lines,
+		FirstSeq: first,
+		NextSeq:  offset + int64(len(lines)),
+		Path:     l.path,
+	}
+}
+
+// writeFileLocked appends line to the log file, rotating it first when it
+// has grown past vlinkLogMaxFileSize. File errors are dropped: the in-memory
+// buffer still works and there is nowhere better to report them.
+func (l *vlinkLogger) writeFileLocked(line VlinkLogLine) {
+	if l.file == nil {
+		if err := l.openLocked(); err != nil {
+			return
+		}
+	}
+	entry := fmt.Sprintf("%s [%s] %s\n", time.UnixMilli(line.Time).Format(time.RFC3339), line.Stream, line.Text)
+	if l.size+int64(len(entry)) > vlinkLogMaxFileSize {
+		l.rotateLocked()
+		if l.file == nil {
+			return
+		}
+	}
+	n, _ := l.file.WriteString(entry)
+	l.size += int64(n)
+}
+
+func (l *vlinkLogger) openLocked() error {
+	path, err := vlinkLogFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	l.path = path
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// rotateLocked shifts vlink.log to vlink.log.1, .1 to .2 and so on, dropping
+// the oldest backup, then reopens an empty vlink.log.
+func (l *vlinkLogger) rotateLocked() {
+	_ = l.file.Close()
+	l.file = nil
+	for i := vlinkLogBackups; i > 0; i-- {
+		src := l.path
+		if i > 1 {
+			src = fmt.Sprintf("%s.%d", l.path, i-1)
+		}
+		_ = os.Rename(src, fmt.Sprintf("%s.%d", l.path, i))
+	}
+	_ = l.openLocked()
+}
+
+// Close flushes and closes the log file. Later writes reopen it.
+func (l *vlinkLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Sync()
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+type vlinkLogWriter struct {
+	logger  *vlinkLogger
+	stream  string
+	partial []byte
+}
+
+func (w *vlinkLogWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.append(w.stream, string(bytes.TrimRight(data[:i], "\r")))
+		data = data[i+1:]
+	}
+	// Never hold on to an unbounded line.
+	if len(data) > vlinkLogMaxLineSize {
+		w.logger.append(w.stream, string(data))
+		data = nil
+	}
+	w.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	vlinkProfileNameMaxLen = 64
+	// vlinkPreviousProfile names the profile a switch saves a live config
+	// under when no profile holds it yet.
+	vlinkPreviousProfile = "previous"
+)
+
+// Stages reported by vlink:profile events while switching.
+const (
+	vlinkProfileStageStopping = "stopping"
+	vlinkProfileStageSwapping = "swapping"
+	vlinkProfileStageStarting = "starting"
+	vlinkProfileStageDone     = "done"
+	vlinkProfileStageError    = "error"
+)
+
+// VlinkProfile is a named vlink config stored under ~/.vlink/profiles.
+type VlinkProfile struct {
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// VlinkProfileStatus is the payload of vlink:profile events. SavedAs is set
+// on the done event when the replaced live config was saved as a profile.
+type VlinkProfileStatus struct {
+	Profile string `json:"profile"`
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	SavedAs string `json:"savedAs,omitempty"`
+}
+
+// ListVlinkProfiles returns the saved profiles sorted by name.
+func (a *App) ListVlinkProfiles() ([]VlinkProfile, error) {
+	dir, err := vlinkProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	active := a.GetSettings().ActiveVlinkProfile
+	profiles := []VlinkProfile{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || validateVlinkProfileName(name) != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, VlinkProfile{
+			Name:      name,
+			Active:    name == active,
+			UpdatedAt: info.ModTime().UnixMilli(),
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles, nil
+}
+
+// GetVlinkProfile returns a profile's config.
+func (a *App) GetVlinkProfile(name string) (VlinkConfig, error) {
+	path, err := vlinkProfilePath(name)
+	if err != nil {
+		return VlinkConfig{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return VlinkConfig{}, fmt.Errorf("vlink profile %q not found", name)
+		}
+		return VlinkConfig{}, err
+	}
+	return VlinkConfig{Path: path, Content: string(data)}, nil
+}
+
+// SaveVlinkProfile creates or updates a profile after validating it. Saving
+// the active profile also updates the live config, but does not restart
+// vlink.
+func (a *App) SaveVlinkProfile(name string, content string) (VlinkConfigValidation, error) {
+	name = strings.TrimSpace(name)
+	path, err := vlinkProfilePath(name)
+	if err != nil {
+		return VlinkConfigValidation{}, err
+	}
+	if name == a.GetSettings().ActiveVlinkProfile {
+		a.vlinkConfigMu.Lock()
+		defer a.vlinkConfigMu.Unlock()
+		return a.saveVlinkConfig(content, "profile "+name)
+	}
+	result := validateVlinkConfig(content, true)
+	if !result.Valid {
+		return result, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return result, err
+	}
+	if err := writeFileAtomic(path, []byte(content), 0o600); err != nil {
+		return result, err
+	}
+	result.Saved = true
+	return result, nil
+}
+
+// DeleteVlinkProfile removes a profile. The active profile cannot be
+// deleted; switch away from it first.
+func (a *App) DeleteVlinkProfile(name string) (string, error) {
+	path, err := vlinkProfilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if name == a.GetSettings().ActiveVlinkProfile {
+		return "", fmt.Errorf("vlink profile %q is active", name)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return "vlink profile deleted", nil
+}
+
+// SwitchVlinkProfile makes name the active profile: it stops vlink, installs
+// the profile as the live config and, if vlink was running, starts it again
+// through StartVlink. A live config no profile holds, such as one edited
+// before profiles were used, is first saved as the "previous" profile (or
+// "previous 2" and so on). Progress is reported as vlink:profile events.
+// Switches and config saves are serialised.
+func (a *App) SwitchVlinkProfile(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	fail := func(err error) (string, error) {
+		a.emitVlinkProfileStatus(name, vlinkProfileStageError, err.Error())
+		return "", err
+	}
+	a.vlinkConfigMu.Lock()
+	defer a.vlinkConfigMu.Unlock()
+
+	profile, err := a.GetVlinkProfile(name)
+	if err != nil {
+		return fail(err)
+	}
+	kept, err := a.keepUnprofiledVlinkConfig()
+	if err != nil {
+		return fail(fmt.Errorf("failed to save the current config as a profile: %w", err))
+	}
+
+	wasRunning := a.isVlinkRunning()
+	if wasRunning {
+		a.emitVlinkProfileStatus(name, vlinkProfileStageStopping, "正在停止 vlink…")
+		if _, err := a.StopVlink(); err != nil {
+			return fail(err)
+		}
+	}
+
+	a.emitVlinkProfileStatus(name, vlinkProfileStageSwapping, "正在切换配置…")
+	// The pointer moves first so saving the live config syncs into the new
+	// profile rather than overwriting the old one.
+	previous := a.GetSettings().ActiveVlinkProfile
+	if err := a.setActiveVlinkProfile(name); err != nil {
+		return fail(err)
+	}
+	result, err := a.saveVlinkConfig(profile.Content, "switched to profile "+name)
+	if err == nil && !result.Saved {
+		err = fmt.Errorf("vlink profile %q has config errors", name)
+	}
+	if err != nil {
+		_ = a.setActiveVlinkProfile(previous)
+		return fail(err)
+	}
+
+	if wasRunning {
+		a.emitVlinkProfileStatus(name, vlinkProfileStageStarting, "正在启动 vlink…")
+		if _, err := a.StartVlink(); err != nil {
+			return fail(err)
+		}
+	}
+	if kept != "" {
+		a.emitVlinkProfile(VlinkProfileStatus{
+			Profile: name,
+			Stage:   vlinkProfileStageDone,
+			Message: fmt.Sprintf("已切换到 %s，原配置已保存为配置档 %s", name, kept),
+			SavedAs: kept,
+		})
+		return fmt.Sprintf("vlink profile switched, previous config saved as profile %q", kept), nil
+	}
+	a.emitVlinkProfileStatus(name, vlinkProfileStageDone, "已切换到 "+name)
+	return "vlink profile switched", nil
+}
+
+// keepUnprofiledVlinkConfig saves the live config as a new profile unless a
+// profile already holds the same content, and returns the profile's name.
+func (a *App) keepUnprofiledVlinkConfig() (string, error) {
+	data, err := os.ReadFile(currentVlinkConfigPath())
+	if os.IsNotExist(err) || (err == nil && strings.TrimSpace(string(data)) == "") {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	profiles, err := a.ListVlinkProfiles()
+	if err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		taken[profile.Name] = true
+		existing, err := a.GetVlinkProfile(profile.Name)
+		if err == nil && existing.Content == string(data) {
+			return "", nil
+		}
+	}
+	name := vlinkPreviousProfile
+	for n := 2; taken[name]; n++ {
+		name = fmt.Sprintf("%s %d", vlinkPreviousProfile, n)
+	}
+	path, err := vlinkProfilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	return name, writeFileAtomic(path, data, 0o600)
+}
+
+func (a *App) setActiveVlinkProfile(name string) error {
+	a.settingsMu.Lock()
+	a.settings.ActiveVlinkProfile = name
+	settings := a.settings
+	a.settingsMu.Unlock()
+	return saveSettingsToDisk(settings)
+}
+
+// syncActiveVlinkProfile copies a saved live config back into the active
+// profile so the two never drift apart.
+func (a *App) syncActiveVlinkProfile(content string) error {
+	name := a.GetSettings().ActiveVlinkProfile
+	if name == "" {
+		return nil
+	}
+	path, err := vlinkProfilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(content), 0o600)
+}
+
+func (a *App) emitVlinkProfileStatus(profile string, stage string, message string) {
+	a.emitVlinkProfile(VlinkProfileStatus{Profile: profile, Stage: stage, Message: message})
+}
+
+func (a *App) emitVlinkProfile(status VlinkProfileStatus) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, "vlink:profile", status)
+}
+
+func vlinkProfilesDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".vlink", "profiles"), nil
+}
+
+func vlinkProfilePath(name string) (string, error) {
+	if err := validateVlinkProfileName(name); err != nil {
+		return "", err
+	}
+	dir, err := vlinkProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// validateVlinkProfileName keeps names usable as file names on every
+// platform.
+func validateVlinkProfileName(name string) error {
+	if name == "" || strings.TrimSpace(name) != name {
+		return fmt.Errorf("vlink profile name is empty or has surrounding spaces")
+	}
+	if utf8.RuneCountInString(name) > vlinkProfileNameMaxLen {
+		return fmt.Errorf("vlink profile name is longer than %d characters", vlinkProfileNameMaxLen)
+	}
+	if strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:*?"<>|`) {
+		return fmt.Errorf("vlink profile name %q contains invalid characters", name)
+	}
+	for _, r := range name {
+		if r < 0x20 {
+			return fmt.Errorf("vlink profile name %q contains invalid characters", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	subscriptionFetchTimeout = 30 * time.Second
+	// subscriptionMaxBytes caps a subscription body; real ones are a few
+	// hundred KB at most.
+	subscriptionMaxBytes = 10 << 20
+	// subscriptionCheckInterval is how often the scheduler looks for
+	// subscriptions due a refresh.
+	subscriptionCheckInterval = time.Hour
+	// defaultSubscriptionRefreshHours is the default of
+	// AppSettings.VlinkSubscriptionRefreshHours.
+	defaultSubscriptionRefreshHours = 24
+)
+
+// VlinkSubscription is an imported subscription and the result of its last
+// refresh.
+type VlinkSubscription struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Name      string `json:"name"`
+	Servers   int    `json:"servers"`
+	Skipped   int    `json:"skipped"`
+	UpdatedAt int64  `json:"updatedAt"`
+	LastError string `json:"lastError"`
+}
+
+// ImportVlinkSubscription fetches a subscription, decodes its servers and
+// merges them into the vlink config as outbounds tagged "<id>/<name>". A
+// subscription imported before is refreshed in place: its old outbounds are
+// replaced and other outbounds are left alone. vlink picks up the new
+// servers the next time it starts.
+func (a *App) ImportVlinkSubscription(rawURL string) (VlinkSubscription, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return VlinkSubscription{}, fmt.Errorf("subscription URL must be an http or https URL")
+	}
+	// Fetch before taking the lock so the other subscription bindings are
+	// not held up by a slow server.
+	nodes, skipped, fetchErr := a.fetchSubscriptionNodes(rawURL)
+
+	a.subscriptionsMu.Lock()
+	defer a.subscriptionsMu.Unlock()
+
+	subscriptions, err := loadVlinkSubscriptions()
+	if err != nil {
+		return VlinkSubscription{}, err
+	}
+	subscription := VlinkSubscription{ID: subscriptionID(rawURL), URL: rawURL, Name: parsed.Hostname()}
+	index := -1
+	for i, existing := range subscriptions {
+		if existing.ID == subscription.ID {
+			subscription, index = existing, i
+			break
+		}
+	}
+	if err := a.recordSubscriptionRefresh(&subscription, nodes, skipped, fetchErr); err != nil {
+		return subscription, err
+	}
+	if index >= 0 {
+		subscriptions[index] = subscription
+	} else {
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscription, saveVlinkSubscriptions(subscriptions)
+}
+
+// ListVlinkSubscriptions returns the imported subscriptions.
+func (a *App) ListVlinkSubscriptions() ([]VlinkSubscription, error) {
+	a.subscriptionsMu.Lock()
+	defer a.subscriptionsMu.Unlock()
+	return loadVlinkSubscriptions()
+}
+
+// RefreshVlinkSubscriptions refreshes every subscription now. A failure is
+// recorded in that subscription's LastError and does not stop the others.
+func (a *App) RefreshVlinkSubscriptions() ([]VlinkSubscription, error) {
+	return a.refreshVlinkSubscriptions(func(VlinkSubscription) bool { return true })
+}
+
+// RemoveVlinkSubscription forgets a subscription and removes its outbounds
+// from the vlink config.
+func (a *App) RemoveVlinkSubscription(id string) (string, error) {
+	a.subscriptionsMu.Lock()
+	defer a.subscriptionsMu.Unlock()
+
+	subscriptions, err := loadVlinkSubscriptions()
+	if err != nil {
+		return "", err
+	}
+	kept := subscriptions[:0]
+	var removed *VlinkSubscription
+	for i := range subscriptions {
+		if subscriptions[i].ID == id {
+			subscription := subscriptions[i]
+			removed = &subscription
+			continue
+		}
+		kept = append(kept, subscriptions[i])
+	}
+	if removed == nil {
+		return "", fmt.Errorf("subscription %s not found", id)
+	}
+	if err := a.applySubscriptionOutbounds(*removed, nil); err != nil {
+		return "", err
+	}
+	if err := saveVlinkSubscriptions(kept); err != nil {
+		return "", err
+	}
+	return "subscription removed", nil
+}
+
+// runSubscriptionScheduler refreshes subscriptions older than
+// AppSettings.VlinkSubscriptionRefreshHours, reporting each through a
+// vlink:subscription event. A setting of 0 turns refreshing off.
+func (a *App) runSubscriptionScheduler() {
+	ticker := time.NewTicker(subscriptionCheckInterval)
+	defer ticker.Stop()
+	for {
+		if hours := a.GetSettings().VlinkSubscriptionRefreshHours; hours > 0 {
+			interval := time.Duration(hours) * time.Hour
+			_, _ = a.refreshVlinkSubscriptions(func(subscription VlinkSubscription) bool {
+				return time.Since(time.UnixMilli(subscription.UpdatedAt)) >= interval
+			})
+		}
+		select {
+		case <-a.lifetime.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshVlinkSubscriptions refreshes the subscriptions due says to. The
+// fetches run without subscriptionsMu; the lock is taken again to apply and
+// record the results, skipping subscriptions removed in the meantime.
+func (a *App) refreshVlinkSubscriptions(due func(VlinkSubscription) bool) ([]VlinkSubscription, error) {
+	a.subscriptionsMu.Lock()
+	subscriptions, err := loadVlinkSubscriptions()
+	a.subscriptionsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	type fetched struct {
+		nodes   []subscriptionNode
+		skipped int
+		err     error
+	}
+	results := make(map[string]fetched)
+	for _, subscription := range subscriptions {
+		if due(subscription) {
+			nodes, skipped, err := a.fetchSubscriptionNodes(subscription.URL)
+			results[subscription.ID] = fetched{nodes, skipped, err}
+		}
+	}
+	if len(results) == 0 {
+		return subscriptions, nil
+	}
+
+	a.subscriptionsMu.Lock()
+	defer a.subscriptionsMu.Unlock()
+	subscriptions, err = loadVlinkSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		result, ok := results[subscriptions[i].ID]
+		if !ok {
+			continue
+		}
+		_ = a.recordSubscriptionRefresh(&subscriptions[i], result.nodes, result.skipped, result.err)
+		a.emitVlinkSubscription(subscriptions[i])
+	}
+	return subscriptions, saveVlinkSubscriptions(subscriptions)
+}
+
+// fetchSubscriptionNodes downloads and decodes a subscription.
+func (a *App) fetchSubscriptionNodes(rawURL string) ([]subscriptionNode, int, error) {
+	ctx, done := a.beginTask()
+	defer done()
+	data, err := fetchSubscription(ctx, rawURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decodeSubscription(data)
+}
+
+// recordSubscriptionRefresh applies fetched servers, or the error fetching
+// them, to one subscription and records the outcome in it. UpdatedAt moves
+// on failure too so a broken link is not retried every hour. Callers hold
+// subscriptionsMu.
+func (a *App) recordSubscriptionRefresh(subscription *VlinkSubscription, nodes []subscriptionNode, skipped int, err error) error {
+	subscription.UpdatedAt = time.Now().UnixMilli()
+	if err == nil {
+		err = a.applySubscriptionOutbounds(*subscription, nodes)
+	}
+	subscription.LastError = ""
+	if err != nil {
+		subscription.LastError = err.Error()
+		return err
+	}
+	subscription.Servers = len(nodes)
+	subscription.Skipped = skipped
+	return nil
+}
+
+// applySubscriptionOutbounds replaces the subscription's outbounds in the
+// config vlink runs with, which may be /etc/vlink/config.json, and saves the
+// result through the usual validation and history. Saving writes
+// ~/.vlink/config.json, so a system config is carried over in full rather
+// than shadowed by a home config holding only the imported outbounds. The
+// whole read-merge-save holds vlinkConfigMu so a config saved meanwhile is
+// not overwritten.
+func (a *App) applySubscriptionOutbounds(subscription VlinkSubscription, nodes []subscriptionNode) error {
+	a.vlinkConfigMu.Lock()
+	defer a.vlinkConfigMu.Unlock()
+	data, err := os.ReadFile(currentVlinkConfigPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read vlink config: %w", err)
+	}
+	current := string(data)
+	outbounds := make([]map[string]interface{}, 0, len(nodes))
+	used := make(map[string]bool)
+	for _, node := range nodes {
+		tag := subscription.ID + "/" + node.Name
+		for n := 2; used[tag]; n++ {
+			tag = fmt.Sprintf("%s/%s (%d)", subscription.ID, node.Name, n)
+		}
+		used[tag] = true
+		outbound := make(map[string]interface{}, len(node.Outbound)+1)
+		for key, value := range node.Outbound {
+			outbound[key] = value
+		}
+		outbound["tag"] = tag
+		outbounds = append(outbounds, outbound)
+	}
+	content, err := mergeSubscriptionOutbounds(current, subscription.ID+"/", outbounds)
+	if err != nil {
+		return err
+	}
+	if content == current {
+		return nil
+	}
+	comment := "subscription " + subscription.Name
+	if len(nodes) == 0 {
+		comment = "removed subscription " + subscription.Name
+	}
+	result, err := a.saveVlinkConfig(content, comment)
+	if err != nil {
+		return err
+	}
+	if !result.Saved {
+		for _, diagnostic := range result.Diagnostics {
+			if diagnostic.Severity == diagnosticError {
+				return fmt.Errorf("merged config is invalid: %s", diagnostic.Message)
+			}
+		}
+		return fmt.Errorf("merged config is invalid")
+	}
+	return nil
+}
+
+// mergeSubscriptionOutbounds returns content with the outbounds whose tag
+// starts with prefix replaced by outbounds. They go where the old ones were;
+// a first import appends them, since vlink routes through the first
+// outbound by default and that should stay the user's choice. Top-level
+// keys keep their order.
+func mergeSubscriptionOutbounds(content string, prefix string, outbounds []map[string]interface{}) (string, error) {
+	if strings.TrimSpace(content) == "" {
+		content = defaultVlinkConfigContent()
+	}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return "", fmt.Errorf("vlink config must be a JSON object")
+	}
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse vlink config: %w", err)
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return "", fmt.Errorf("failed to parse vlink config: %w", err)
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	var existing []json.RawMessage
+	if raw, ok := values["outbounds"]; ok {
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return "", fmt.Errorf("outbounds must be an array")
+		}
+	} else {
+		keys = append(keys, "outbounds")
+	}
+	merged := make([]interface{}, 0, len(existing)+len(outbounds))
+	inserted := false
+	for _, raw := range existing {
+		var outbound struct {
+			Tag string `json:"tag"`
+		}
+		_ = json.Unmarshal(raw, &outbound)
+		if !strings.HasPrefix(outbound.Tag, prefix) {
+			merged = append(merged, raw)
+			continue
+		}
+		if !inserted {
+			for _, replacement := range outbounds {
+				merged = append(merged, replacement)
+			}
+			inserted = true
+		}
+	}
+	if !inserted {
+		for _, outbound := range outbounds {
+			merged = append(merged, outbound)
+		}
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	values["outbounds"] = raw
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(values[key])
+	}
+	out.WriteByte('}')
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", "  "); err != nil {
+		return "", err
+	}
+	indented.WriteByte('\n')
+	if normalized, err := normalizeJSONIndent(content); err == nil && normalized == indented.String() {
+		// Nothing changed; keep the user's formatting.
+		return content, nil
+	}
+	return indented.String(), nil
+}
+
+func normalizeJSONIndent(content string) (string, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(strings.TrimSpace(content)), "", "  "); err != nil {
+		return "", err
+	}
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+func fetchSubscription(ctx context.Context, rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, subscriptionFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "domour-copilot/"+appVersion)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("subscription fetch failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, subscriptionMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subscription: %w", err)
+	}
+	if len(data) > subscriptionMaxBytes {
+		return nil, fmt.Errorf("subscription is larger than %d MB", subscriptionMaxBytes>>20)
+	}
+	return data, nil
+}
+
+// subscriptionID is a short stable ID derived from the URL, used as the tag
+// prefix of the subscription's outbounds.
+func subscriptionID(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return "sub-" + hex.EncodeToString(sum[:4])
+}
+
+func (a *App) emitVlinkSubscription(subscription VlinkSubscription) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, "vlink:subscription", subscription)
+}
+
+func vlinkSubscriptionsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".domour", "subscriptions.json"), nil
+}
+
+func loadVlinkSubscriptions() ([]VlinkSubscription, error) {
+	path, err := vlinkSubscriptionsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []VlinkSubscription{}, nil
+		}
+		return nil, err
+	}
+	subscriptions := []VlinkSubscription{}
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return subscriptions, nil
+}
+
+func saveVlinkSubscriptions(subscriptions []VlinkSubscription) error {
+	path, err := vlinkSubscriptionsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(subscriptions, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Subscription URLs usually embed an access token.
+	return writeFileAtomic(path, data, 0o600)
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// subscriptionNode is one server from a subscription, already converted to a
+// vlink outbound. The outbound has no tag; the importer assigns one.
+type subscriptionNode struct {
+	Name     string
+	Outbound map[string]interface{}
+}
+
+// decodeSubscription decodes a subscription body: Clash YAML, or a list of
+// vmess/vless/trojan/ss URIs that is usually base64 encoded. Entries it
+// cannot use are counted in skipped rather than failing the whole import.
+// It does no I/O so it can be exercised with fixture files.
+func decodeSubscription(data []byte) (nodes []subscriptionNode, skipped int, err error) {
+	text := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	if text == "" {
+		return nil, 0, fmt.Errorf("subscription is empty")
+	}
+	if isClashSubscription(text) {
+		return decodeClashSubscription([]byte(text))
+	}
+	if !strings.Contains(text, "://") {
+		decoded, err := decodeBase64Loose(text)
+		if err != nil {
+			return nil, 0, fmt.Errorf("subscription is neither Clash YAML, a URI list nor base64")
+		}
+		text = string(decoded)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		node, err := parseProxyURI(line)
+		if err != nil {
+			skipped++
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, skipped, fmt.Errorf("subscription has no supported servers")
+	}
+	return nodes, skipped, nil
+}
+
+func isClashSubscription(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimRight(line, " \r"), "proxies:") {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64Loose accepts standard and URL-safe base64, padded or not and
+// wrapped across lines, as subscription providers use all of them.
+func decodeBase64Loose(text string) ([]byte, error) {
+	text = strings.Join(strings.Fields(text), "")
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(text); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64")
+}
+
+// parseProxyURI converts one share link to a node.
+func parseProxyURI(raw string) (subscriptionNode, error) {
+	scheme, _, ok := strings.Cut(raw, "://")
+	if !ok {
+		return subscriptionNode{}, fmt.Errorf("not a URI")
+	}
+	switch strings.ToLower(scheme) {
+	case "vmess":
+		return parseVmessURI(raw)
+	case "vless", "trojan":
+		return parseVlessOrTrojanURI(raw)
+	case "ss":
+		return parseShadowsocksURI(raw)
+	}
+	return subscriptionNode{}, fmt.Errorf("unsupported scheme %q", scheme)
+}
+
+// parseVmessURI decodes the v2rayN format: vmess:// followed by base64 JSON.
+func parseVmessURI(raw string) (subscriptionNode, error) {
+	decoded, err := decodeBase64Loose(raw[len("vmess://"):])
+	if err != nil {
+		return subscriptionNode{}, fmt.Errorf("vmess link is not base64")
+	}
+	var link struct {
+		PS   string      `json:"ps"`
+		Add  string      `json:"add"`
+		Port json.Number `json:"port"`
+		ID   string      `json:"id"`
+		Aid  json.Number `json:"aid"`
+		Scy  string      `json:"scy"`
+		Net  string      `json:"net"`
+		Host string      `json:"host"`
+		Path string      `json:"path"`
+		TLS  string      `json:"tls"`
+		SNI  string      `json:"sni"`
+		FP   string      `json:"fp"`
+	}
+	// Some providers quote numbers and some do not; UseNumber takes both.
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&link); err != nil {
+		return subscriptionNode{}, fmt.Errorf("vmess link is not valid JSON: %w", err)
+	}
+	port, err := subscriptionPort(link.Port.String())
+	if err != nil {
+		return subscriptionNode{}, err
+	}
+	if link.Add == "" || link.ID == "" {
+		return subscriptionNode{}, fmt.Errorf("vmess link is missing address or id")
+	}
+	alterID, _ := strconv.Atoi(link.Aid.String())
+	security := link.Scy
+	if security == "" {
+		security = "auto"
+	}
+	stream := streamOptions{
+		Network:     link.Net,
+		Host:        link.Host,
+		Path:        link.Path,
+		ServiceName: link.Path,
+		SNI:         link.SNI,
+		Fingerprint: link.FP,
+	}
+	if link.TLS == "tls" {
+		stream.Security = "tls"
+	}
+	return subscriptionNode{
+		Name: nodeName(link.PS, link.Add, port),
+		Outbound: map[string]interface{}{
+			"protocol": "vmess",
+			"settings": map[string]interface{}{
+				"vnext": []interface{}{map[string]interface{}{
+					"address": link.Add,
+					"port":    port,
+					"users": []interface{}{map[string]interface{}{
+						"id":       link.ID,
+						"alterId":  alterID,
+						"security": security,
+					}},
+				}},
+			},
+			"streamSettings": stream.settings(),
+		},
+	}, nil
+}
+
+// parseVlessOrTrojanURI decodes vless://uuid@host:port?params#name and
+// trojan://password@host:port?params#name.
+func parseVlessOrTrojanURI(raw string) (subscriptionNode, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return subscriptionNode{}, err
+	}
+	protocol := strings.ToLower(parsed.Scheme)
+	secret := parsed.User.Username()
+	host := parsed.Hostname()
+	port, err := subscriptionPort(parsed.Port())
+	if err != nil {
+		return subscriptionNode{}, err
+	}
+	if secret == "" || host == "" {
+		return subscriptionNode{}, fmt.Errorf("%s link is missing credentials or host", protocol)
+	}
+	query := parsed.Query()
+	stream := streamOptions{
+		Network:     query.Get("type"),
+		Security:    query.Get("security"),
+		Host:        query.Get("host"),
+		Path:        query.Get("path"),
+		ServiceName: query.Get("serviceName"),
+		SNI:         query.Get("sni"),
+		Fingerprint: query.Get("fp"),
+		PublicKey:   query.Get("pbk"),
+		ShortID:     query.Get("sid"),
+		Insecure:    query.Get("allowInsecure") == "1",
+	}
+	outbound := map[string]interface{}{"protocol": protocol}
+	if protocol == "vless" {
+		user := map[string]interface{}{"id": secret, "encryption": "none"}
+		if flow := query.Get("flow"); flow != "" {
+			user["flow"] = flow
+		}
+		outbound["settings"] = map[string]interface{}{
+			"vnext": []interface{}{map[string]interface{}{
+				"address": host,
+				"port":    port,
+				"users":   []interface{}{user},
+			}},
+		}
+	} else {
+		// Trojan always runs over TLS.
+		if stream.Security == "" || stream.Security == "none" {
+			stream.Security = "tls"
+		}
+		outbound["settings"] = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{
+				"address":  host,
+				"port":     port,
+				"password": secret,
+			}},
+		}
+	}
+	outbound["streamSettings"] = stream.settings()
+	return subscriptionNode{Name: nodeName(parsed.Fragment, host, port), Outbound: outbound}, nil
+}
+
+// parseShadowsocksURI decodes SIP002 links, ss://base64(method:password)@
+// host:port#name, and the legacy ss://base64(method:password@host:port)#name.
+func parseShadowsocksURI(raw string) (subscriptionNode, error) {
+	body := raw[len("ss://"):]
+	body, fragment, _ := strings.Cut(body, "#")
+	name, _ := url.PathUnescape(fragment)
+	// Plugin parameters are not supported by vlink and are dropped.
+	body, _, _ = strings.Cut(body, "?")
+	body = strings.TrimSuffix(body, "/")
+
+	var userInfo, hostPort string
+	if at := strings.LastIndex(body, "@"); at >= 0 {
+		userInfo, hostPort = body[:at], body[at+1:]
+		if decoded, err := decodeBase64Loose(userInfo); err == nil && strings.Contains(string(decoded), ":") {
+			userInfo = string(decoded)
+		} else if unescaped, err := url.PathUnescape(userInfo); err == nil {
+			userInfo = unescaped
+		}
+	} else {
+		decoded, err := decodeBase64Loose(body)
+		if err != nil {
+			return subscriptionNode{}, fmt.Errorf("ss link is not base64")
+		}
+		at := strings.LastIndex(string(decoded), "@")
+		if at < 0 {
+			return subscriptionNode{}, fmt.Errorf("ss link is missing host")
+		}
+		userInfo, hostPort = string(decoded[:at]), string(decoded[at+1:])
+	}
+	method, password, ok := strings.Cut(userInfo, ":")
+	if !ok || method == "" {
+		return subscriptionNode{}, fmt.Errorf("ss link is missing method or password")
+	}
+	host, portText, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return subscriptionNode{}, fmt.Errorf("ss link has an invalid host: %w", err)
+	}
+	port, err := subscriptionPort(portText)
+	if err != nil {
+		return subscriptionNode{}, err
+	}
+	return shadowsocksNode(nodeName(name, host, port), host, port, method, password), nil
+}
+
+func shadowsocksNode(name string, host string, port int, method string, password string) subscriptionNode {
+	return subscriptionNode{
+		Name: name,
+		Outbound: map[string]interface{}{
+			"protocol": "shadowsocks",
+			"settings": map[string]interface{}{
+				"servers": []interface{}{map[string]interface{}{
+					"address":  host,
+					"port":     port,
+					"method":   method,
+					"password": password,
+				}},
+			},
+		},
+	}
+}
+
+// clashProxy holds the fields of a Clash proxy entry this importer uses.
+type clashProxy struct {
+	Name           string `yaml:"name"`
+	Type           string `yaml:"type"`
+	Server         string `yaml:"server"`
+	Port           string `yaml:"port"`
+	UUID           string `yaml:"uuid"`
+	AlterID        int    `yaml:"alterId"`
+	Cipher         string `yaml:"cipher"`
+	Password       string `yaml:"password"`
+	TLS            bool   `yaml:"tls"`
+	SkipCertVerify bool   `yaml:"skip-cert-verify"`
+	ServerName     string `yaml:"servername"`
+	SNI            string `yaml:"sni"`
+	Network        string `yaml:"network"`
+	Flow           string `yaml:"flow"`
+	ClientFinger   string `yaml:"client-fingerprint"`
+	WSOpts         struct {
+		Path    string            `yaml:"path"`
+		Headers map[string]string `yaml:"headers"`
+	} `yaml:"ws-opts"`
+	GRPCOpts struct {
+		ServiceName string `yaml:"grpc-service-name"`
+	} `yaml:"grpc-opts"`
+	RealityOpts struct {
+		PublicKey string `yaml:"public-key"`
+		ShortID   string `yaml:"short-id"`
+	} `yaml:"reality-opts"`
+}
+
+// decodeClashSubscription reads the proxies list of a Clash config.
+func decodeClashSubscription(data []byte) ([]subscriptionNode, int, error) {
+	var config struct {
+		Proxies []clashProxy `yaml:"proxies"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Clash subscription: %w", err)
+	}
+	var nodes []subscriptionNode
+	skipped := 0
+	for _, proxy := range config.Proxies {
+		node, err := clashProxyNode(proxy)
+		if err != nil {
+			skipped++
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, skipped, fmt.Errorf("subscription has no supported servers")
+	}
+	return nodes, skipped, nil
+}
+
+func clashProxyNode(proxy clashProxy) (subscriptionNode, error) {
+	port, err := subscriptionPort(proxy.Port)
+	if err != nil {
+		return subscriptionNode{}, err
+	}
+	if proxy.Server == "" {
+		return subscriptionNode{}, fmt.Errorf("proxy %q has no server", proxy.Name)
+	}
+	name := nodeName(proxy.Name, proxy.Server, port)
+	if proxy.Type == "ss" {
+		if proxy.Cipher == "" {
+			return subscriptionNode{}, fmt.Errorf("proxy %q has no cipher", proxy.Name)
+		}
+		return shadowsocksNode(name, proxy.Server, port, proxy.Cipher, proxy.Password), nil
+	}
+
+	sni := proxy.SNI
+	if sni == "" {
+		sni = proxy.ServerName
+	}
+	stream := streamOptions{
+		Network:     proxy.Network,
+		Host:        proxy.WSOpts.Headers["Host"],
+		Path:        proxy.WSOpts.Path,
+		ServiceName: proxy.GRPCOpts.ServiceName,
+		SNI:         sni,
+		Fingerprint: proxy.ClientFinger,
+		PublicKey:   proxy.RealityOpts.PublicKey,
+		ShortID:     proxy.RealityOpts.ShortID,
+		Insecure:    proxy.SkipCertVerify,
+	}
+	switch {
+	case stream.PublicKey != "":
+		stream.Security = "reality"
+	case proxy.TLS || proxy.Type == "trojan":
+		stream.Security = "tls"
+	}
+
+	var settings map[string]interface{}
+	switch proxy.Type {
+	case "vmess", "vless":
+		if proxy.UUID == "" {
+			return subscriptionNode{}, fmt.Errorf("proxy %q has no uuid", proxy.Name)
+		}
+		user := map[string]interface{}{"id": proxy.UUID}
+		if proxy.Type == "vmess" {
+			user["alterId"] = proxy.AlterID
+			user["security"] = "auto"
+			if proxy.Cipher != "" {
+				user["security"] = proxy.Cipher
+			}
+		} else {
+			user["encryption"] = "none"
+			if proxy.Flow != "" {
+				user["flow"] = proxy.Flow
+			}
+		}
+		settings = map[string]interface{}{
+			"vnext": []interface{}{map[string]interface{}{
+				"address": proxy.Server,
+				"port":    port,
+				"users":   []interface{}{user},
+			}},
+		}
+	case "trojan":
+		settings = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{
+				"address":  proxy.Server,
+				"port":     port,
+				"password": proxy.Password,
+			}},
+		}
+	default:
+		return subscriptionNode{}, fmt.Errorf("unsupported Clash proxy type %q", proxy.Type)
+	}
+	return subscriptionNode{
+		Name: name,
+		Outbound: map[string]interface{}{
+			"protocol":       proxy.Type,
+			"settings":       settings,
+			"streamSettings": stream.settings(),
+		},
+	}, nil
+}
+
+// streamOptions collects the transport and TLS parameters shared by the
+// link formats.
+type streamOptions struct {
+	Network     string
+	Security    string
+	Host        string
+	Path        string
+	ServiceName string
+	SNI         string
+	Fingerprint string
+	PublicKey   string
+	ShortID     string
+	Insecure    bool
+}
+
+// settings renders the options as a vlink streamSettings object.
+func (o streamOptions) settings() map[string]interface{} {
+	network := strings.ToLower(o.Network)
+	if network == "" {
+		network = "tcp"
+	}
+	stream := map[string]interface{}{"network": network}
+	switch network {
+	case "ws":
+		ws := map[string]interface{}{}
+		if o.Path != "" {
+			ws["path"] = o.Path
+		}
+		if o.Host != "" {
+			ws["headers"] = map[string]interface{}{"Host": o.Host}
+		}
+		stream["wsSettings"] = ws
+	case "grpc":
+		stream["grpcSettings"] = map[string]interface{}{"serviceName": o.ServiceName}
+	}
+
+	serverName := o.SNI
+	if serverName == "" {
+		serverName = o.Host
+	}
+	switch strings.ToLower(o.Security) {
+	case "tls":
+		tls := map[string]interface{}{}
+		if serverName != "" {
+			tls["serverName"] = serverName
+		}
+		if o.Fingerprint != "" {
+			tls["fingerprint"] = o.Fingerprint
+		}
+		if o.Insecure {
+			tls["allowInsecure"] = true
+		}
+		stream["security"] = "tls"
+		stream["tlsSettings"] = tls
+	case "reality":
+		stream["security"] = "reality"
+		stream["realitySettings"] = map[string]interface{}{
+			"serverName":  serverName,
+			"fingerprint": o.Fingerprint,
+			"publicKey":   o.PublicKey,
+			"shortId":     o.ShortID,
+		}
+	}
+	return stream
+}
+
+func subscriptionPort(text string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", text)
+	}
+	return port, nil
+}
+
+func nodeName(name string, host string, port int) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata golden files")
+
+// checkGolden compares got with testdata/subscription/<name>, or rewrites it
+// with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "subscription", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "subscription", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func marshalNodes(t *testing.T, nodes []subscriptionNode) []byte {
+	t.Helper()
+	data, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(data, '\n')
+}
+
+func TestDecodeSubscription(t *testing.T) {
+	tests := []struct {
+		fixture string
+		golden  string
+		skipped int
+	}{
+		{"uri-list.b64", "uri-list.golden.json", 2},
+		{"uri-list.txt", "uri-list.golden.json", 2},
+		{"clash.yaml", "clash.golden.json", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			nodes, skipped, err := decodeSubscription(readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.skipped)
+			}
+			checkGolden(t, tt.golden, marshalNodes(t, nodes))
+		})
+	}
+}
+
+func TestDecodeSubscriptionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", " \n", "subscription is empty"},
+		{"not base64", "<html>404</html>", "subscription is neither Clash YAML, a URI list nor base64"},
+		{"nothing supported", "hysteria2://a@b:1\ntuic://c@d:2\n", "subscription has no supported servers"},
+		{"clash without usable proxies", "proxies:\n  - {name: x, type: wireguard, server: a, port: 1}\n", "subscription has no supported servers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeSubscription([]byte(tt.data))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// outboundSummary flattens the fields the link parsers fill in.
+type outboundSummary struct {
+	Name, Protocol, Address, Secret, Network, Security, ServerName string
+	Port                                                           int
+}
+
+func summarizeOutbound(t *testing.T, node subscriptionNode) outboundSummary {
+	t.Helper()
+	data, err := json.Marshal(node.Outbound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outbound struct {
+		Protocol string `json:"protocol"`
+		Settings struct {
+			Vnext []struct {
+				Address string `json:"address"`
+				Port    int    `json:"port"`
+				Users   []struct {
+					ID string `json:"id"`
+				} `json:"users"`
+			} `json:"vnext"`
+			Servers []struct {
+				Address  string `json:"address"`
+				Port     int    `json:"port"`
+				Password string `json:"password"`
+				Method   string `json:"method"`
+			} `json:"servers"`
+		} `json:"settings"`
+		StreamSettings struct {
+			Network         string                      `json:"network"`
+			Security        string                      `json:"security"`
+			TLSSettings     struct{ ServerName string } `json:"tlsSettings"`
+			RealitySettings struct{ ServerName string } `json:"realitySettings"`
+		} `json:"streamSettings"`
+	}
+	if err := json.Unmarshal(data, &outbound); err != nil {
+		t.Fatal(err)
+	}
+	summary := outboundSummary{
+		Name:       node.Name,
+		Protocol:   outbound.Protocol,
+		Network:    outbound.StreamSettings.Network,
+		Security:   outbound.StreamSettings.Security,
+		ServerName: outbound.StreamSettings.TLSSettings.ServerName + outbound.StreamSettings.RealitySettings.ServerName,
+	}
+	if len(outbound.Settings.Vnext) > 0 {
+		summary.Address = outbound.Settings.Vnext[0].Address
+		summary.Port = outbound.Settings.Vnext[0].Port
+		summary.Secret = outbound.Settings.Vnext[0].Users[0].ID
+	}
+	if len(outbound.Settings.Servers) > 0 {
+		server := outbound.Settings.Servers[0]
+		summary.Address, summary.Port, summary.Secret = server.Address, server.Port, server.Password
+		if server.Method != "" {
+			summary.Secret = server.Method + ":" + server.Password
+		}
+	}
+	return summary
+}
+
+func TestParseProxyURIs(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(string) (subscriptionNode, error)
+		uri     string
+		want    outboundSummary
+		wantErr bool
+	}{
+		{
+			name:  "vmess ws tls",
+			parse: parseVmessURI,
+			// {"ps":"VM","add":"vm.example.com","port":443,"id":"uuid-1","aid":0,"net":"ws","host":"cdn.example.com","path":"/p","tls":"tls"}
+			uri:  "vmess://eyJwcyI6IlZNIiwiYWRkIjoidm0uZXhhbXBsZS5jb20iLCJwb3J0Ijo0NDMsImlkIjoidXVpZC0xIiwiYWlkIjowLCJuZXQiOiJ3cyIsImhvc3QiOiJjZG4uZXhhbXBsZS5jb20iLCJwYXRoIjoiL3AiLCJ0bHMiOiJ0bHMifQ==",
+			want: outboundSummary{Name: "VM", Protocol: "vmess", Address: "vm.example.com", Port: 443, Secret: "uuid-1", Network: "ws", Security: "tls", ServerName: "cdn.example.com"},
+		},
+		{
+			name:  "vmess without name or tls",
+			parse: parseVmessURI,
+			// {"add":"10.0.0.1","port":"8080","id":"uuid-2"}
+			uri:  "vmess://eyJhZGQiOiIxMC4wLjAuMSIsInBvcnQiOiI4MDgwIiwiaWQiOiJ1dWlkLTIifQ",
+			want: outboundSummary{Name: "10.0.0.1:8080", Protocol: "vmess", Address: "10.0.0.1", Port: 8080, Secret: "uuid-2", Network: "tcp"},
+		},
+		{
+			name:    "vmess with bad port",
+			parse:   parseVmessURI,
+			uri:     "vmess://eyJhZGQiOiJhIiwicG9ydCI6IjAiLCJpZCI6ImIifQ", // {"add":"a","port":"0","id":"b"}
+			wantErr: true,
+		},
+		{
+			name:    "vmess not base64",
+			parse:   parseVmessURI,
+			uri:     "vmess://%%%",
+			wantErr: true,
+		},
+		{
+			name:  "vless reality",
+			parse: parseVlessOrTrojanURI,
+			uri:   "vless://uuid-3@vl.example.com:443?security=reality&sni=www.microsoft.com&pbk=KEY&type=tcp#VL%20R",
+			want:  outboundSummary{Name: "VL R", Protocol: "vless", Address: "vl.example.com", Port: 443, Secret: "uuid-3", Network: "tcp", Security: "reality", ServerName: "www.microsoft.com"},
+		},
+		{
+			name:  "vless ipv6 grpc",
+			parse: parseVlessOrTrojanURI,
+			uri:   "vless://uuid-4@[2001:db8::1]:8443?type=grpc&serviceName=svc&security=tls",
+			want:  outboundSummary{Name: "[2001:db8::1]:8443", Protocol: "vless", Address: "2001:db8::1", Port: 8443, Secret: "uuid-4", Network: "grpc", Security: "tls"},
+		},
+		{
+			name:  "trojan defaults to tls",
+			parse: parseVlessOrTrojanURI,
+			uri:   "trojan://pa%40ss@tj.example.com:443?sni=tj.example.com#TJ",
+			want:  outboundSummary{Name: "TJ", Protocol: "trojan", Address: "tj.example.com", Port: 443, Secret: "pa@ss", Network: "tcp", Security: "tls", ServerName: "tj.example.com"},
+		},
+		{
+			name:    "trojan without password",
+			parse:   parseVlessOrTrojanURI,
+			uri:     "trojan://tj.example.com:443",
+			wantErr: true,
+		},
+		{
+			name:  "ss SIP002",
+			parse: parseShadowsocksURI,
+			uri:   "ss://YWVzLTEyOC1nY206dGVzdA@192.168.100.1:8888#Example1",
+			want:  outboundSummary{Name: "Example1", Protocol: "shadowsocks", Address: "192.168.100.1", Port: 8888, Secret: "aes-128-gcm:test", Network: ""},
+		},
+		{
+			name:  "ss SIP002 plain userinfo with plugin",
+			parse: parseShadowsocksURI,
+			uri:   "ss://2022-blake3-aes-256-gcm:YctPZ6U7xPPcU%2Bgp3u%2B0tx%2FtRizJN9K8y%2BuKlW2qjlI%3D@ss.example.com:443/?plugin=v2ray-plugin#2022",
+			want:  outboundSummary{Name: "2022", Protocol: "shadowsocks", Address: "ss.example.com", Port: 443, Secret: "2022-blake3-aes-256-gcm:YctPZ6U7xPPcU+gp3u+0tx/tRizJN9K8y+uKlW2qjlI="},
+		},
+		{
+			name:  "ss legacy",
+			parse: parseShadowsocksURI,
+			uri:   "ss://YmYtY2ZiOnRlc3RAMTkyLjE2OC4xMDAuMTo4ODg4#Example2", // bf-cfb:test@192.168.100.1:8888
+			want:  outboundSummary{Name: "Example2", Protocol: "shadowsocks", Address: "192.168.100.1", Port: 8888, Secret: "bf-cfb:test"},
+		},
+		{
+			name:  "ss legacy without name",
+			parse: parseShadowsocksURI,
+			uri:   "ss://YmYtY2ZiOnRlc3RAMTkyLjE2OC4xMDAuMTo4ODg4",
+			want:  outboundSummary{Name: "192.168.100.1:8888", Protocol: "shadowsocks", Address: "192.168.100.1", Port: 8888, Secret: "bf-cfb:test"},
+		},
+		{
+			name:    "ss legacy without host",
+			parse:   parseShadowsocksURI,
+			uri:     "ss://YmYtY2ZiOnRlc3Q", // bf-cfb:test
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := tt.parse(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", node)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summarizeOutbound(t, node); got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSubscriptionOutbounds(t *testing.T) {
+	imported := []map[string]interface{}{
+		{"tag": "sub-test/new 1", "protocol": "trojan"},
+		{"tag": "sub-test/new 2", "protocol": "vless"},
+	}
+	tests := []struct {
+		name      string
+		config    string
+		outbounds []map[string]interface{}
+		golden    string
+	}{
+		{"replaces in place", "merge-config.json", imported, "merge-replaced.golden.json"},
+		{"removes", "merge-config.json", nil, "merge-removed.golden.json"},
+		{"first import appends", "merge-fresh.json", imported, "merge-appended.golden.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := mergeSubscriptionOutbounds(string(readFixture(t, tt.config)), "sub-test/", tt.outbounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, []byte(merged))
+		})
+	}
+
+	t.Run("unchanged content keeps its formatting", func(t *testing.T) {
+		config := string(readFixture(t, "merge-fresh.json"))
+		merged, err := mergeSubscriptionOutbounds(config, "sub-test/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merged != config {
+			t.Errorf("content was rewritten:\n%s", merged)
+		}
+	})
+
+	t.Run("empty config", func(t *testing.T) {
+		merged, err := mergeSubscriptionOutbounds("", "sub-test/", imported[:1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "{\n  \"outbounds\": [\n    {\n      \"protocol\": \"trojan\",\n      \"tag\": \"sub-test/new 1\"\n    }\n  ]\n}\n"
+		if merged != want {
+			t.Errorf("got:\n%s\nwant:\n%s", merged, want)
+		}
+	})
+
+	t.Run("rejects non-object config", func(t *testing.T) {
+		if _, err := mergeSubscriptionOutbounds("[]", "sub-test/", nil); err == nil {
+			t.Error("expected an error")
+		}
+		if _, err := mergeSubscriptionOutbounds(`{"outbounds": {}}`, "sub-test/", nil); err == nil {
+			t.Error("expected an error for non-array outbounds")
+		}
+	})
+}
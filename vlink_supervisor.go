@@ -0,0 +1,224 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	vlinkStateStarting   = "starting"
+	vlinkStateRunning    = "running"
+	vlinkStateCrashed    = "crashed"
+	vlinkStateBackingOff = "backing-off"
+	vlinkStateStopped    = "stopped"
+
+	// vlink is restarted at most vlinkMaxRestarts times in a row; a run
+	// lasting vlinkStableUptime resets the count.
+	vlinkMaxRestarts     = 5
+	vlinkStableUptime    = time.Minute
+	vlinkBackoffBase     = time.Second
+	vlinkBackoffLimit    = time.Minute
+	vlinkUnknownExitCode = -1
+
+	// StopVlink interrupts vlink, kills it after vlinkStopGrace and gives
+	// up waiting vlinkKillWait later.
+	vlinkStopGrace = 3 * time.Second
+	vlinkKillWait  = 2 * time.Second
+)
+
+// VlinkState is the payload of vlink:state events and GetVlinkState.
+type VlinkState struct {
+	State    string `json:"state"`
+	PID      int    `json:"pid"`
+	ExitCode int    `json:"exitCode"`
+	// UptimeMs is how long the last process ran before it exited.
+	UptimeMs int64 `json:"uptimeMs"`
+	Restarts int   `json:"restarts"`
+	// RetryInMs is the backoff delay before the next restart.
+	RetryInMs int64  `json:"retryInMs"`
+	Message   string `json:"message"`
+}
+
+// GetVlinkState returns the supervisor's latest state, or in the systemd run
+// mode the state of the vlink user service when no child is running.
+func (a *App) GetVlinkState() VlinkState {
+	if a.vlinkUsesSystemd() && !a.isVlinkChildRunning() {
+		status, err := vlinkServiceStatus()
+		if err != nil {
+			return VlinkState{State: vlinkStateStopped, ExitCode: vlinkUnknownExitCode, Message: err.Error()}
+		}
+		return vlinkStateFromService(status)
+	}
+
+	a.vlinkMu.Lock()
+	defer a.vlinkMu.Unlock()
+	if a.vlinkState.State == "" {
+		return VlinkState{State: vlinkStateStopped}
+	}
+	return a.vlinkState
+}
+
+// newVlinkCommand builds the vlink child process with its output captured
+// by the app's vlink log.
+func (a *App) newVlinkCommand(binaryPath string, args []string) *exec.Cmd {
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Stdout = a.vlinkLog.writer("stdout")
+	cmd.Stderr = a.vlinkLog.writer("stderr")
+	return cmd
+}
+
+// superviseVlink waits on the running vlink process and restarts it with
+// exponential backoff when it exits without StopVlink being called. It owns
+// cmd.Wait for every process it manages and closes done when it gives up or
+// is stopped.
+func (a *App) superviseVlink(cmd *exec.Cmd, binaryPath string, args []string, stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	restarts := 0
+	startErr := ""
+	for {
+		var uptime time.Duration
+		exitCode := vlinkUnknownExitCode
+		message := startErr
+		if cmd != nil {
+			a.setVlinkState(VlinkState{State: vlinkStateRunning, PID: cmd.Process.Pid, Restarts: restarts})
+			_ = writeVlinkPIDFile(cmd.Process.Pid, binaryPath)
+			started := time.Now()
+			if err := cmd.Wait(); err != nil {
+				message = err.Error()
+			}
+			uptime = time.Since(started)
+			if cmd.ProcessState != nil {
+				exitCode = cmd.ProcessState.ExitCode()
+			}
+		}
+
+		if isClosed(stop) {
+			a.finishVlinkSupervisor(VlinkState{
+				State:    vlinkStateStopped,
+				ExitCode: exitCode,
+				UptimeMs: uptime.Milliseconds(),
+				Restarts: restarts,
+			})
+			return
+		}
+
+		a.setVlinkState(VlinkState{
+			State:    vlinkStateCrashed,
+			ExitCode: exitCode,
+			UptimeMs: uptime.Milliseconds(),
+			Restarts: restarts,
+			Message:  message,
+		})
+		if uptime >= vlinkStableUptime {
+			restarts = 0
+		}
+		if restarts >= vlinkMaxRestarts {
+			a.finishVlinkSupervisor(VlinkState{
+				State:    vlinkStateStopped,
+				ExitCode: exitCode,
+				UptimeMs: uptime.Milliseconds(),
+				Restarts: restarts,
+				Message:  "vlink keeps crashing, giving up after repeated restarts",
+			})
+			return
+		}
+		restarts++
+
+		delay := vlinkBackoff(restarts)
+		a.setVlinkState(VlinkState{
+			State:     vlinkStateBackingOff,
+			ExitCode:  exitCode,
+			UptimeMs:  uptime.Milliseconds(),
+			Restarts:  restarts,
+			RetryInMs: delay.Milliseconds(),
+		})
+		select {
+		case <-stop:
+			a.finishVlinkSupervisor(VlinkState{State: vlinkStateStopped, ExitCode: exitCode, Restarts: restarts})
+			return
+		case <-time.After(delay):
+		}
+
+		a.setVlinkState(VlinkState{State: vlinkStateStarting, Restarts: restarts})
+		cmd = a.newVlinkCommand(binaryPath, args)
+		startErr = ""
+		if err := cmd.Start(); err != nil {
+			cmd = nil
+			startErr = err.Error()
+			continue
+		}
+
+		// StopVlink reads vlinkCmd under the same lock it closes stop with,
+		// so either it signals this process or we see stop closed here.
+		a.vlinkMu.Lock()
+		a.vlinkCmd = cmd
+		stopped := isClosed(stop)
+		a.vlinkMu.Unlock()
+		if stopped {
+			_ = cmd.Process.Signal(os.Interrupt)
+		}
+	}
+}
+
+func (a *App) finishVlinkSupervisor(state VlinkState) {
+	removeVlinkPIDFile()
+	a.vlinkMu.Lock()
+	defer a.vlinkMu.Unlock()
+	a.vlinkCmd = nil
+	a.vlinkStop = nil
+	a.vlinkDone = nil
+	a.setVlinkStateLocked(state)
+}
+
+func (a *App) setVlinkState(state VlinkState) {
+	a.vlinkMu.Lock()
+	defer a.vlinkMu.Unlock()
+	a.setVlinkStateLocked(state)
+}
+
+func (a *App) setVlinkStateLocked(state VlinkState) {
+	a.vlinkState = state
+	a.logVlinkState(state)
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, "vlink:state", state)
+}
+
+func (a *App) logVlinkState(state VlinkState) {
+	switch state.State {
+	case vlinkStateRunning:
+		a.vlinkLog.logf("vlink running, pid %d", state.PID)
+	case vlinkStateCrashed:
+		a.vlinkLog.logf("vlink exited with code %d after %s %s", state.ExitCode, time.Duration(state.UptimeMs)*time.Millisecond, state.Message)
+	case vlinkStateBackingOff:
+		a.vlinkLog.logf("restarting vlink in %s (attempt %d)", time.Duration(state.RetryInMs)*time.Millisecond, state.Restarts)
+	case vlinkStateStopped:
+		a.vlinkLog.logf("vlink stopped %s", state.Message)
+	}
+}
+
+// vlinkBackoff doubles the delay with every consecutive restart.
+func vlinkBackoff(restarts int) time.Duration {
+	delay := vlinkBackoffBase
+	for i := 1; i < restarts && delay < vlinkBackoffLimit; i++ {
+		delay *= 2
+	}
+	if delay > vlinkBackoffLimit {
+		delay = vlinkBackoffLimit
+	}
+	return delay
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
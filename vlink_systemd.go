@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How vlink is run. The systemd mode is only honoured on Linux.
+const (
+	// vlinkRunModeProcess runs vlink as a supervised child of the app.
+	vlinkRunModeProcess = "process"
+	// vlinkRunModeSystemd runs vlink as a systemd --user service that
+	// outlives the app.
+	vlinkRunModeSystemd = "systemd"
+)
+
+const (
+	vlinkServiceName    = "domour-vlink.service"
+	systemctlTimeout    = 15 * time.Second
+	vlinkServiceRestart = 2 * time.Second
+)
+
+// vlinkServiceProperties are read from systemctl --user show.
+var vlinkServiceProperties = []string{
+	"LoadState",
+	"ActiveState",
+	"SubState",
+	"UnitFileState",
+	"MainPID",
+	"NRestarts",
+	"ExecMainStatus",
+	"FragmentPath",
+}
+
+// VlinkServiceStatus describes the vlink systemd user unit.
+type VlinkServiceStatus struct {
+	// Supported is false where systemctl --user is unavailable.
+	Supported   bool   `json:"supported"`
+	Installed   bool   `json:"installed"`
+	Enabled     bool   `json:"enabled"`
+	ActiveState string `json:"activeState"`
+	SubState    string `json:"subState"`
+	MainPID     int    `json:"mainPid"`
+	Restarts    int    `json:"restarts"`
+	ExitCode    int    `json:"exitCode"`
+	UnitPath    string `json:"unitPath"`
+	Message     string `json:"message"`
+}
+
+// GetVlinkServiceStatus reports the state of the vlink user unit.
+func (a *App) GetVlinkServiceStatus() (VlinkServiceStatus, error) {
+	return vlinkServiceStatus()
+}
+
+// InstallVlinkService writes the vlink user unit from the current binary and
+// config paths and enables it at login or disables it. It does not start or
+// stop the service.
+func (a *App) InstallVlinkService(enable bool) (VlinkServiceStatus, error) {
+	if runtime.GOOS != "linux" {
+		return VlinkServiceStatus{}, fmt.Errorf("systemd services are only available on Linux")
+	}
+	if _, err := a.writeVlinkServiceUnit(); err != nil {
+		return VlinkServiceStatus{}, err
+	}
+	action := "disable"
+	if enable {
+		action = "enable"
+	}
+	if _, err := systemctlUser(action, vlinkServiceName); err != nil {
+		return VlinkServiceStatus{}, err
+	}
+	return vlinkServiceStatus()
+}
+
+// UninstallVlinkService stops and disables the vlink user unit and removes
+// its unit file.
+func (a *App) UninstallVlinkService() (string, error) {
+	path, err := vlinkServiceUnitPath()
+	if err != nil {
+		return "", err
+	}
+	if !fileExists(path) {
+		return "vlink service is not installed", nil
+	}
+	if _, err := systemctlUser("disable", "--now", vlinkServiceName); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if _, err := systemctlUser("daemon-reload"); err != nil {
+		return "", err
+	}
+	if !a.isVlinkChildRunning() {
+		a.setVlinkState(VlinkState{State: vlinkStateStopped})
+	}
+	return "vlink service removed", nil
+}
+
+// isVlinkChildRunning reports whether the app runs vlink itself or has
+// adopted one.
+func (a *App) isVlinkChildRunning() bool {
+	a.vlinkMu.Lock()
+	defer a.vlinkMu.Unlock()
+	return a.vlinkStop != nil || a.vlinkAdopted != 0
+}
+
+func (a *App) vlinkUsesSystemd() bool {
+	return runtime.GOOS == "linux" && a.GetSettings().VlinkRunMode == vlinkRunModeSystemd
+}
+
+// isVlinkRunning reports whether vlink runs in either mode.
+func (a *App) isVlinkRunning() bool {
+	if a.isVlinkChildRunning() {
+		return true
+	}
+	if !a.vlinkUsesSystemd() {
+		return false
+	}
+	status, err := vlinkServiceStatus()
+	return err == nil && vlinkServiceActive(status)
+}
+
+// startVlinkService is StartVlink for the systemd mode. The unit is
+// rewritten first so it always points at the current binary and config.
+func (a *App) startVlinkService() (string, error) {
+	if a.isVlinkChildRunning() {
+		return "vlink is already running", nil
+	}
+
+	configPath, created, err := resolveVlinkConfigPath()
+	if err != nil {
+		return "failed to resolve vlink config", err
+	}
+	if created {
+		a.emitVlinkConfigRequired(configPath)
+		return "vlink config required", fmt.Errorf("vlink config required")
+	}
+	changed, err := a.writeVlinkServiceUnit()
+	if err != nil {
+		return "failed to install vlink service", err
+	}
+	status, err := vlinkServiceStatus()
+	if err != nil {
+		return "failed to query vlink service", err
+	}
+	if vlinkServiceActive(status) && !changed {
+		a.setVlinkState(vlinkStateFromService(status))
+		return "vlink is already running", nil
+	}
+	if err := a.checkVlinkConflict(status.MainPID); err != nil {
+		return "vlink is already running outside the app", err
+	}
+
+	a.setVlinkState(VlinkState{State: vlinkStateStarting})
+	// restart picks up a rewritten unit when the service was already up.
+	if _, err := systemctlUser("restart", vlinkServiceName); err != nil {
+		a.setVlinkState(VlinkState{State: vlinkStateStopped, ExitCode: vlinkUnknownExitCode, Message: err.Error()})
+		return "failed to start vlink service", err
+	}
+	if status, err = vlinkServiceStatus(); err == nil {
+		a.setVlinkState(vlinkStateFromService(status))
+	}
+	return "vlink service started", nil
+}
+
+func (a *App) stopVlinkService() (string, error) {
+	status, err := vlinkServiceStatus()
+	if err != nil {
+		return "", err
+	}
+	if !status.Installed || !vlinkServiceActive(status) {
+		return "vlink is not running", nil
+	}
+	if _, err := systemctlUser("stop", vlinkServiceName); err != nil {
+		return "", err
+	}
+	a.setVlinkState(VlinkState{State: vlinkStateStopped})
+	return "vlink service stopped", nil
+}
+
+// writeVlinkServiceUnit writes the unit file when its content changed and
+// reloads systemd. It reports whether the unit changed.
+func (a *App) writeVlinkServiceUnit() (bool, error) {
+	binaryPath, err := vlinkBinaryPath()
+	if err != nil {
+		return false, err
+	}
+	if !fileExists(binaryPath) {
+		return false, fmt.Errorf("vlink is not installed at %s", binaryPath)
+	}
+	configPath, _, err := resolveVlinkConfigPath()
+	if err != nil {
+		return false, err
+	}
+	path, err := vlinkServiceUnitPath()
+	if err != nil {
+		return false, err
+	}
+	unit := vlinkServiceUnit(binaryPath, configPath)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == unit {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create systemd user directory: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(unit), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write vlink service: %w", err)
+	}
+	if _, err := systemctlUser("daemon-reload"); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// vlinkServiceUnit renders the user unit that runs binaryPath with
+// configPath. systemd restarts it on failure the way the in-app supervisor
+// would.
+func vlinkServiceUnit(binaryPath string, configPath string) string {
+	execStart := systemdQuote(binaryPath)
+	if configPath != "" {
+		execStart += " -config " + systemdQuote(configPath)
+	}
+	return fmt.Sprintf(`# Generated by Domour Copilot; changes are overwritten when vlink starts.
+[Unit]
+Description=vlink proxy (Domour Copilot)
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s
+Restart=on-failure
+RestartSec=%d
+
+[Install]
+WantedBy=default.target
+`, execStart, int(vlinkServiceRestart/time.Second))
+}
+
+// systemdQuote quotes a word for ExecStart, escaping what systemd would
+// otherwise expand.
+func systemdQuote(word string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	return `"` + replacer.Replace(word) + `"`
+}
+
+func vlinkServiceUnitPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user", vlinkServiceName), nil
+}
+
+func vlinkServiceStatus() (VlinkServiceStatus, error) {
+	if runtime.GOOS != "linux" {
+		return VlinkServiceStatus{}, nil
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return VlinkServiceStatus{Message: "systemctl not found"}, nil
+	}
+	output, err := systemctlUser("show", vlinkServiceName, "--property="+strings.Join(vlinkServiceProperties, ","))
+	if err != nil {
+		return VlinkServiceStatus{Message: err.Error()}, err
+	}
+	status := parseVlinkServiceStatus(parseSystemctlShow(output))
+	status.Supported = true
+	return status, nil
+}
+
+// parseSystemctlShow parses the Key=Value lines of systemctl show.
+func parseSystemctlShow(output string) map[string]string {
+	properties := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && key != "" {
+			properties[key] = value
+		}
+	}
+	return properties
+}
+
+func parseVlinkServiceStatus(properties map[string]string) VlinkServiceStatus {
+	number := func(key string) int {
+		n, _ := strconv.Atoi(properties[key])
+		return n
+	}
+	unitFileState := properties["UnitFileState"]
+	return VlinkServiceStatus{
+		Installed:   properties["LoadState"] == "loaded",
+		Enabled:     unitFileState == "enabled" || unitFileState == "enabled-runtime",
+		ActiveState: properties["ActiveState"],
+		SubState:    properties["SubState"],
+		MainPID:     number("MainPID"),
+		Restarts:    number("NRestarts"),
+		ExitCode:    number("ExecMainStatus"),
+		UnitPath:    properties["FragmentPath"],
+	}
+}
+
+func vlinkServiceActive(status VlinkServiceStatus) bool {
+	switch status.ActiveState {
+	case "active", "activating", "reloading":
+		return true
+	}
+	return false
+}
+
+// vlinkStateFromService maps the unit's state onto the states the in-app
+// supervisor reports, so the UI treats both modes alike.
+func vlinkStateFromService(status VlinkServiceStatus) VlinkState {
+	state := VlinkState{PID: status.MainPID, Restarts: status.Restarts, ExitCode: status.ExitCode}
+	switch status.ActiveState {
+	case "active", "reloading":
+		state.State = vlinkStateRunning
+	case "activating":
+		state.State = vlinkStateStarting
+		if status.SubState == "auto-restart" {
+			state.State = vlinkStateBackingOff
+		}
+	case "failed":
+		state.State = vlinkStateCrashed
+		state.Message = "vlink service failed: " + status.SubState
+	default:
+		state.State = vlinkStateStopped
+	}
+	return state
+}
+
+func systemctlUser(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), systemctlTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		text := strings.TrimSpace(string(output))
+		if text == "" {
+			text = err.Error()
+		}
+		return "", fmt.Errorf("systemctl --user %s failed: %s", strings.Join(args, " "), text)
+	}
+	return string(output), nil
+}